@@ -46,13 +46,18 @@ func (p *Program) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token // the token.LET token
+	Token token.Token // the token.LET or token.CONST token
 	Name  *Identifier
 	Value Expression
 }
 
 func (ls *LetStatement) statementNode() {}
 
+// IsConst reports whether the statement declares a constant
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.IsType(token.CONST)
+}
+
 // TokenLiteral returns the literal value of the token
 func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
@@ -120,6 +125,188 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+// ImportStatement represents import "path" as name
+type ImportStatement struct {
+	Token token.Token // the 'import' token
+	Path  *StringLiteral
+	Alias *Identifier
+}
+
+func (is *ImportStatement) statementNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+// String returns the string representation of the import statement
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(`"` + is.Path.String() + `"`)
+	out.WriteString(" as ")
+	out.WriteString(is.Alias.String())
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ExportStatement represents export let name = value (or export const), which makes name visible to importers
+type ExportStatement struct {
+	Token       token.Token // the 'export' token
+	Declaration *LetStatement
+}
+
+func (es *ExportStatement) statementNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (es *ExportStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+// String returns the string representation of the export statement
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Declaration.String()
+}
+
+// StructStatement represents struct Name { field, ... }
+type StructStatement struct {
+	Token  token.Token // the 'struct' token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (ss *StructStatement) TokenLiteral() string {
+	return ss.Token.Literal
+}
+
+// String returns the string representation of the struct statement
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	var fields []string
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	out.WriteString(ss.TokenLiteral() + " ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// EnumStatement represents enum Name { Variant(field, ...), ... }
+type EnumStatement struct {
+	Token    token.Token // the 'enum' token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+// EnumVariant is one variant of an enum declaration. Fields is empty for variants without payload.
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+// String returns the string representation of the enum variant
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+
+	var fields []string
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+func (es *EnumStatement) statementNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (es *EnumStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+// String returns the string representation of the enum statement
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+
+	var variants []string
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString(es.TokenLiteral() + " ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// ClassStatement represents class Name extends Superclass { method(params) { body } ... }
+type ClassStatement struct {
+	Token      token.Token // the 'class' token
+	Name       *Identifier
+	Superclass *Identifier // nil without extends
+	Methods    []*MethodDefinition
+}
+
+// MethodDefinition is a single method of a class declaration
+type MethodDefinition struct {
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+// String returns the string representation of the method definition
+func (md *MethodDefinition) String() string {
+	var params []string
+	for _, p := range md.Function.Parameters {
+		params = append(params, p.String())
+	}
+
+	return md.Name.String() + "(" + strings.Join(params, ", ") + ") " + md.Function.Body.String()
+}
+
+func (cs *ClassStatement) statementNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (cs *ClassStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+// String returns the string representation of the class statement
+func (cs *ClassStatement) String() string {
+	var out bytes.Buffer
+
+	var methods []string
+	for _, m := range cs.Methods {
+		methods = append(methods, m.String())
+	}
+
+	out.WriteString(cs.TokenLiteral() + " ")
+	out.WriteString(cs.Name.String())
+	if cs.Superclass != nil {
+		out.WriteString(" extends ")
+		out.WriteString(cs.Superclass.String())
+	}
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(methods, " "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
 	Expression Expression
@@ -210,6 +397,31 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// RangeExpression is start..end, or start..=end when Inclusive
+type RangeExpression struct {
+	Token     token.Token // The '..' or '..=' token
+	Start     Expression
+	End       Expression
+	Inclusive bool
+}
+
+func (re *RangeExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (re *RangeExpression) TokenLiteral() string {
+	return re.Token.Literal
+}
+
+// String returns the string representation of the range expression
+func (re *RangeExpression) String() string {
+	operator := ".."
+	if re.Inclusive {
+		operator = "..="
+	}
+
+	return "(" + re.Start.String() + operator + re.End.String() + ")"
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -227,6 +439,22 @@ func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (nl *NullLiteral) TokenLiteral() string {
+	return nl.Token.Literal
+}
+
+// String returns the string representation of the null literal
+func (nl *NullLiteral) String() string {
+	return nl.Token.Literal
+}
+
 type IfExpression struct {
 	Token       token.Token // The 'if' token
 	Condition   Expression
@@ -258,6 +486,49 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// MatchExpression represents match subject { pattern => expr, ... }
+type MatchExpression struct {
+	Token   token.Token // The 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+// MatchArm is a single pattern => expr arm of a match expression
+type MatchArm struct {
+	Pattern Expression
+	Body    Expression
+}
+
+// String returns the string representation of the match arm
+func (ma *MatchArm) String() string {
+	return ma.Pattern.String() + " => " + ma.Body.String()
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+// String returns the string representation of match expression
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	var arms []string
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match ")
+	out.WriteString(me.Subject.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
 type BlockStatement struct {
 	Token      token.Token // The { token
 	Statements []Statement
@@ -282,9 +553,11 @@ func (bs *BlockStatement) String() string {
 }
 
 type FunctionLiteral struct {
-	Token      token.Token // The 'fn' token
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token       token.Token // The 'fn' token
+	Parameters  []*Identifier
+	Body        *BlockStatement
+	IsGenerator bool // the body yields, so calling the function returns a generator
+	IsAsync     bool // declared with async, so calling the function returns a future
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -303,6 +576,9 @@ func (fl *FunctionLiteral) String() string {
 		params = append(params, p.String())
 	}
 
+	if fl.IsAsync {
+		out.WriteString("async ")
+	}
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -312,6 +588,31 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// MacroLiteral is macro(x, y) { body }. Macros are expanded before evaluation:
+// their arguments are passed unevaluated as quotes and the body must return a quote.
+type MacroLiteral struct {
+	Token      token.Token // The 'macro' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (ml *MacroLiteral) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (ml *MacroLiteral) TokenLiteral() string {
+	return ml.Token.Literal
+}
+
+// String returns the string representation of the macro literal
+func (ml *MacroLiteral) String() string {
+	var params []string
+	for _, p := range ml.Parameters {
+		params = append(params, p.String())
+	}
+
+	return ml.TokenLiteral() + "(" + strings.Join(params, ", ") + ") " + ml.Body.String()
+}
+
 type CallExpression struct {
 	Token     token.Token // The '(' token
 	Function  Expression
@@ -359,6 +660,38 @@ func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+// TemplateLiteral represents an interpolated string such as "Hello ${name}"
+type TemplateLiteral struct {
+	Token token.Token  // The token.TEMPLATE_HEAD token
+	Parts []Expression // literal text as *StringLiteral, interleaved with the embedded expressions
+}
+
+func (tl *TemplateLiteral) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (tl *TemplateLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+// String returns the string representation of the template literal
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for _, part := range tl.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(str.Value)
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token // The '[' token
 	Elements []Expression
@@ -388,9 +721,10 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token // The '[' token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The '[' or '?[' token
+	Left     Expression
+	Index    Expression
+	Optional bool // true for left?[index], which yields null when left is null
 }
 
 func (ie *IndexExpression) expressionNode() {}
@@ -406,16 +740,158 @@ func (ie *IndexExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
-	out.WriteString("[")
+	out.WriteString(ie.TokenLiteral())
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
 
 	return out.String()
 }
 
+// HashPair is a single key-value entry of a hash literal
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// PropertyExpression represents left.property, a lookup of the string key property,
+// or a method call when it is the function of a CallExpression
+type PropertyExpression struct {
+	Token    token.Token // The '.' or '?.' token
+	Left     Expression
+	Property *Identifier
+	Optional bool // true for left?.property, which yields null when left is null
+}
+
+func (pe *PropertyExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (pe *PropertyExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+// String returns the string representation of the property expression
+func (pe *PropertyExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.TokenLiteral())
+	out.WriteString(pe.Property.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// YieldExpression represents yield value, or yield* value which yields every element of value in turn
+type YieldExpression struct {
+	Token    token.Token // The 'yield' token
+	Value    Expression
+	Delegate bool
+}
+
+func (ye *YieldExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (ye *YieldExpression) TokenLiteral() string {
+	return ye.Token.Literal
+}
+
+// String returns the string representation of the yield expression
+func (ye *YieldExpression) String() string {
+	if ye.Delegate {
+		return ye.TokenLiteral() + "* " + ye.Value.String()
+	}
+	return ye.TokenLiteral() + " " + ye.Value.String()
+}
+
+// AwaitExpression represents await value
+type AwaitExpression struct {
+	Token token.Token // The 'await' token
+	Value Expression
+}
+
+func (ae *AwaitExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (ae *AwaitExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+// String returns the string representation of the await expression
+func (ae *AwaitExpression) String() string {
+	return "(" + ae.TokenLiteral() + " " + ae.Value.String() + ")"
+}
+
+// AssignExpression represents target = value. The target is always a PropertyExpression.
+type AssignExpression struct {
+	Token  token.Token // The '=' token
+	Target *PropertyExpression
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+// String returns the string representation of the assign expression
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// SliceExpression represents left[start:end:step]. Omitted bounds are nil.
+type SliceExpression struct {
+	Token    token.Token // The '[' or '?[' token
+	Left     Expression
+	Start    Expression
+	End      Expression
+	Step     Expression
+	Optional bool // true for left?[start:end], which yields null when left is null
+}
+
+func (se *SliceExpression) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+// String returns the string representation of the slice expression
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString(se.TokenLiteral())
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 type HashLiteral struct {
 	Token token.Token // The '{' token
-	Pairs map[Expression]Expression
+	Pairs []HashPair  // in source order
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -430,8 +906,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	var pairs []string
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -440,3 +916,114 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// ComprehensionClause is the `for a, b in iterable if condition` part of a comprehension.
+// Condition is nil when there is no if.
+type ComprehensionClause struct {
+	Variables []*Identifier
+	Iterable  Expression
+	Condition Expression
+}
+
+// String returns the string representation of the clause
+func (cc *ComprehensionClause) String() string {
+	var variables []string
+	for _, v := range cc.Variables {
+		variables = append(variables, v.String())
+	}
+
+	out := "for " + strings.Join(variables, ", ") + " in " + cc.Iterable.String()
+	if cc.Condition != nil {
+		out += " if " + cc.Condition.String()
+	}
+
+	return out
+}
+
+// ListComprehension is [element for x in iterable if condition]
+type ListComprehension struct {
+	Token   token.Token // The '[' token
+	Element Expression
+	Clause  ComprehensionClause
+}
+
+func (lc *ListComprehension) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (lc *ListComprehension) TokenLiteral() string {
+	return lc.Token.Literal
+}
+
+// String returns the string representation of the list comprehension
+func (lc *ListComprehension) String() string {
+	return "[" + lc.Element.String() + " " + lc.Clause.String() + "]"
+}
+
+// HashComprehension is {key: value for k, v in iterable if condition}
+type HashComprehension struct {
+	Token  token.Token // The '{' token
+	Key    Expression
+	Value  Expression
+	Clause ComprehensionClause
+}
+
+func (hc *HashComprehension) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (hc *HashComprehension) TokenLiteral() string {
+	return hc.Token.Literal
+}
+
+// String returns the string representation of the hash comprehension
+func (hc *HashComprehension) String() string {
+	return "{" + hc.Key.String() + ":" + hc.Value.String() + " " + hc.Clause.String() + "}"
+}
+
+// SetLiteral is a set literal such as {1, 2}. An empty {} is always a HashLiteral.
+type SetLiteral struct {
+	Token    token.Token // The '{' token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (sl *SetLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+// String returns the string representation of the set literal
+func (sl *SetLiteral) String() string {
+	var elements []string
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// TupleLiteral is a tuple literal such as (1, 2), (1,) or ()
+type TupleLiteral struct {
+	Token    token.Token // The '(' token
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) expressionNode() {}
+
+// TokenLiteral returns the literal value of the token
+func (tl *TupleLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+// String returns the string representation of the tuple literal
+func (tl *TupleLiteral) String() string {
+	var elements []string
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
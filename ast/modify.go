@@ -0,0 +1,317 @@
+package ast
+
+// ModifierFunc returns the node to put in place of node
+type ModifierFunc func(node Node) Node
+
+// Modify returns a copy of node rewritten bottom-up: the children of a node are modified
+// before the modifier is called on the node itself, and the result takes its place in the
+// copy of its parent. The tree passed in is left unchanged, so the same tree can be modified
+// again, e.g. each time a quote is evaluated. A statement replaced by nil is removed from its
+// program or block. Any other replacement that cannot take the place of the original, e.g. an
+// expression returned for an identifier in a binding position, is ignored.
+func Modify(node Node, modifier ModifierFunc) Node {
+	return modifier(mapChildren(node, func(child Node) Node {
+		return Modify(child, modifier)
+	}))
+}
+
+// RewriteFunc returns the node to put in place of node, and whether Rewrite should
+// go on to rewrite the children of that replacement
+type RewriteFunc func(node Node) (replacement Node, descend bool)
+
+// Rewrite returns a copy of node rewritten top-down: rewrite is called on a node before
+// its children, so it can replace a whole subtree and decide whether the replacement is
+// rewritten further. Like Modify, it leaves the tree passed in unchanged, removes statements
+// replaced by nil and ignores other replacements that cannot take the place of the original.
+func Rewrite(node Node, rewrite RewriteFunc) Node {
+	replacement, descend := rewrite(node)
+	if !descend || replacement == nil {
+		return replacement
+	}
+
+	return mapChildren(replacement, func(child Node) Node {
+		return Rewrite(child, rewrite)
+	})
+}
+
+// mapChildren returns a shallow copy of node whose children are replaced by f(child),
+// in source order. Leaves are returned as they are.
+func mapChildren(node Node, f func(Node) Node) Node {
+	switch node := node.(type) {
+	case *Program:
+		copied := *node
+		copied.Statements = mapStatements(node.Statements, f)
+		return &copied
+	case *BlockStatement:
+		copied := *node
+		copied.Statements = mapStatements(node.Statements, f)
+		return &copied
+	case *ExpressionStatement:
+		copied := *node
+		copied.Expression = mapExpression(node.Expression, f)
+		return &copied
+	case *LetStatement:
+		copied := *node
+		copied.Name = mapIdentifier(node.Name, f)
+		copied.Value = mapExpression(node.Value, f)
+		return &copied
+	case *ReturnStatement:
+		copied := *node
+		copied.ReturnValue = mapExpression(node.ReturnValue, f)
+		return &copied
+	case *ImportStatement:
+		copied := *node
+		if node.Path != nil {
+			if path, ok := f(node.Path).(*StringLiteral); ok {
+				copied.Path = path
+			}
+		}
+		copied.Alias = mapIdentifier(node.Alias, f)
+		return &copied
+	case *ExportStatement:
+		copied := *node
+		if node.Declaration != nil {
+			if declaration, ok := f(node.Declaration).(*LetStatement); ok {
+				copied.Declaration = declaration
+			}
+		}
+		return &copied
+	case *StructStatement:
+		copied := *node
+		copied.Name = mapIdentifier(node.Name, f)
+		copied.Fields = mapIdentifiers(node.Fields, f)
+		return &copied
+	case *EnumStatement:
+		copied := *node
+		copied.Name = mapIdentifier(node.Name, f)
+		copied.Variants = make([]*EnumVariant, len(node.Variants))
+		for i, variant := range node.Variants {
+			copied.Variants[i] = &EnumVariant{
+				Name:   mapIdentifier(variant.Name, f),
+				Fields: mapIdentifiers(variant.Fields, f),
+			}
+		}
+		return &copied
+	case *ClassStatement:
+		copied := *node
+		copied.Name = mapIdentifier(node.Name, f)
+		copied.Superclass = mapIdentifier(node.Superclass, f)
+		copied.Methods = make([]*MethodDefinition, len(node.Methods))
+		for i, method := range node.Methods {
+			function := method.Function
+			if function != nil {
+				if mapped, ok := f(function).(*FunctionLiteral); ok {
+					function = mapped
+				}
+			}
+			copied.Methods[i] = &MethodDefinition{Name: mapIdentifier(method.Name, f), Function: function}
+		}
+		return &copied
+	case *PrefixExpression:
+		copied := *node
+		copied.Right = mapExpression(node.Right, f)
+		return &copied
+	case *InfixExpression:
+		copied := *node
+		copied.Left = mapExpression(node.Left, f)
+		copied.Right = mapExpression(node.Right, f)
+		return &copied
+	case *RangeExpression:
+		copied := *node
+		copied.Start = mapExpression(node.Start, f)
+		copied.End = mapExpression(node.End, f)
+		return &copied
+	case *IfExpression:
+		copied := *node
+		copied.Condition = mapExpression(node.Condition, f)
+		copied.Consequence = mapBlock(node.Consequence, f)
+		copied.Alternative = mapBlock(node.Alternative, f)
+		return &copied
+	case *MatchExpression:
+		copied := *node
+		copied.Subject = mapExpression(node.Subject, f)
+		copied.Arms = make([]*MatchArm, len(node.Arms))
+		for i, arm := range node.Arms {
+			copied.Arms[i] = &MatchArm{
+				Pattern: mapExpression(arm.Pattern, f),
+				Body:    mapExpression(arm.Body, f),
+			}
+		}
+		return &copied
+	case *FunctionLiteral:
+		copied := *node
+		copied.Parameters = mapIdentifiers(node.Parameters, f)
+		copied.Body = mapBlock(node.Body, f)
+		return &copied
+	case *MacroLiteral:
+		copied := *node
+		copied.Parameters = mapIdentifiers(node.Parameters, f)
+		copied.Body = mapBlock(node.Body, f)
+		return &copied
+	case *CallExpression:
+		copied := *node
+		copied.Function = mapExpression(node.Function, f)
+		copied.Arguments = mapExpressions(node.Arguments, f)
+		return &copied
+	case *TemplateLiteral:
+		copied := *node
+		copied.Parts = mapExpressions(node.Parts, f)
+		return &copied
+	case *ArrayLiteral:
+		copied := *node
+		copied.Elements = mapExpressions(node.Elements, f)
+		return &copied
+	case *SetLiteral:
+		copied := *node
+		copied.Elements = mapExpressions(node.Elements, f)
+		return &copied
+	case *TupleLiteral:
+		copied := *node
+		copied.Elements = mapExpressions(node.Elements, f)
+		return &copied
+	case *HashLiteral:
+		copied := *node
+		copied.Pairs = make([]HashPair, len(node.Pairs))
+		for i, pair := range node.Pairs {
+			copied.Pairs[i] = HashPair{
+				Key:   mapExpression(pair.Key, f),
+				Value: mapExpression(pair.Value, f),
+			}
+		}
+		return &copied
+	case *IndexExpression:
+		copied := *node
+		copied.Left = mapExpression(node.Left, f)
+		copied.Index = mapExpression(node.Index, f)
+		return &copied
+	case *SliceExpression:
+		copied := *node
+		copied.Left = mapExpression(node.Left, f)
+		copied.Start = mapExpression(node.Start, f)
+		copied.End = mapExpression(node.End, f)
+		copied.Step = mapExpression(node.Step, f)
+		return &copied
+	case *PropertyExpression:
+		copied := *node
+		copied.Left = mapExpression(node.Left, f)
+		copied.Property = mapIdentifier(node.Property, f)
+		return &copied
+	case *AssignExpression:
+		copied := *node
+		if node.Target != nil {
+			if target, ok := f(node.Target).(*PropertyExpression); ok {
+				copied.Target = target
+			}
+		}
+		copied.Value = mapExpression(node.Value, f)
+		return &copied
+	case *YieldExpression:
+		copied := *node
+		copied.Value = mapExpression(node.Value, f)
+		return &copied
+	case *AwaitExpression:
+		copied := *node
+		copied.Value = mapExpression(node.Value, f)
+		return &copied
+	case *ListComprehension:
+		copied := *node
+		copied.Element = mapExpression(node.Element, f)
+		copied.Clause = mapClause(node.Clause, f)
+		return &copied
+	case *HashComprehension:
+		copied := *node
+		copied.Key = mapExpression(node.Key, f)
+		copied.Value = mapExpression(node.Value, f)
+		copied.Clause = mapClause(node.Clause, f)
+		return &copied
+	default:
+		return node
+	}
+}
+
+// mapStatements maps a list of statements. A statement mapped to nil is removed from the list.
+func mapStatements(statements []Statement, f func(Node) Node) []Statement {
+	if statements == nil {
+		return nil
+	}
+
+	mapped := make([]Statement, 0, len(statements))
+	for _, statement := range statements {
+		replacement := f(statement)
+		if replacement == nil {
+			continue
+		}
+
+		if replacement, ok := replacement.(Statement); ok {
+			mapped = append(mapped, replacement)
+		} else {
+			mapped = append(mapped, statement)
+		}
+	}
+	return mapped
+}
+
+func mapExpressions(expressions []Expression, f func(Node) Node) []Expression {
+	if expressions == nil {
+		return nil
+	}
+
+	mapped := make([]Expression, len(expressions))
+	for i, expression := range expressions {
+		mapped[i] = mapExpression(expression, f)
+	}
+	return mapped
+}
+
+// mapExpression maps an optional expression, keeping it when the replacement is not an expression
+func mapExpression(expression Expression, f func(Node) Node) Expression {
+	if expression == nil {
+		return nil
+	}
+	if mapped, ok := f(expression).(Expression); ok {
+		return mapped
+	}
+	return expression
+}
+
+func mapIdentifiers(identifiers []*Identifier, f func(Node) Node) []*Identifier {
+	if identifiers == nil {
+		return nil
+	}
+
+	mapped := make([]*Identifier, len(identifiers))
+	for i, identifier := range identifiers {
+		mapped[i] = mapIdentifier(identifier, f)
+	}
+	return mapped
+}
+
+// mapIdentifier maps an optional identifier, keeping it when the replacement is not an identifier
+func mapIdentifier(identifier *Identifier, f func(Node) Node) *Identifier {
+	if identifier == nil {
+		return nil
+	}
+	if mapped, ok := f(identifier).(*Identifier); ok {
+		return mapped
+	}
+	return identifier
+}
+
+// mapBlock maps an optional block, keeping it when the replacement is not a block
+func mapBlock(block *BlockStatement, f func(Node) Node) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	if mapped, ok := f(block).(*BlockStatement); ok {
+		return mapped
+	}
+	return block
+}
+
+func mapClause(clause ComprehensionClause, f func(Node) Node) ComprehensionClause {
+	return ComprehensionClause{
+		Variables: mapIdentifiers(clause.Variables, f),
+		Iterable:  mapExpression(clause.Iterable, f),
+		Condition: mapExpression(clause.Condition, f),
+	}
+}
@@ -0,0 +1,121 @@
+package ast
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gtihub.com/yudai2929/monkey-lang/token"
+	"testing"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Value: 2} }
+	ident := func(name string) *Identifier { return &Identifier{Value: name} }
+	block := func(e Expression) *BlockStatement {
+		return &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: e}}}
+	}
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+		return &IntegerLiteral{Value: 2}
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{&InfixExpression{Left: one(), Operator: "+", Right: two()}, &InfixExpression{Left: two(), Operator: "+", Right: two()}},
+		{&InfixExpression{Left: two(), Operator: "+", Right: one()}, &InfixExpression{Left: two(), Operator: "+", Right: two()}},
+		{&PrefixExpression{Operator: "-", Right: one()}, &PrefixExpression{Operator: "-", Right: two()}},
+		{&RangeExpression{Start: one(), End: one()}, &RangeExpression{Start: two(), End: two()}},
+		{&IndexExpression{Left: one(), Index: one()}, &IndexExpression{Left: two(), Index: two()}},
+		{&SliceExpression{Left: one(), End: one()}, &SliceExpression{Left: two(), End: two()}},
+		{
+			&IfExpression{Condition: one(), Consequence: block(one()), Alternative: block(one())},
+			&IfExpression{Condition: two(), Consequence: block(two()), Alternative: block(two())},
+		},
+		{&ReturnStatement{ReturnValue: one()}, &ReturnStatement{ReturnValue: two()}},
+		{&LetStatement{Name: ident("x"), Value: one()}, &LetStatement{Name: ident("x"), Value: two()}},
+		{
+			&FunctionLiteral{Parameters: []*Identifier{ident("x")}, Body: block(one())},
+			&FunctionLiteral{Parameters: []*Identifier{ident("x")}, Body: block(two())},
+		},
+		{&CallExpression{Function: ident("f"), Arguments: []Expression{one()}}, &CallExpression{Function: ident("f"), Arguments: []Expression{two()}}},
+		{&ArrayLiteral{Elements: []Expression{one(), one()}}, &ArrayLiteral{Elements: []Expression{two(), two()}}},
+		{&SetLiteral{Elements: []Expression{one()}}, &SetLiteral{Elements: []Expression{two()}}},
+		{&TupleLiteral{Elements: []Expression{one()}}, &TupleLiteral{Elements: []Expression{two()}}},
+		{
+			&HashLiteral{Pairs: []HashPair{{Key: one(), Value: one()}}},
+			&HashLiteral{Pairs: []HashPair{{Key: two(), Value: two()}}},
+		},
+		{
+			&MatchExpression{Subject: one(), Arms: []*MatchArm{{Pattern: one(), Body: one()}}},
+			&MatchExpression{Subject: two(), Arms: []*MatchArm{{Pattern: two(), Body: two()}}},
+		},
+		{&PropertyExpression{Left: one(), Property: ident("p")}, &PropertyExpression{Left: two(), Property: ident("p")}},
+		{
+			&AssignExpression{Target: &PropertyExpression{Left: one(), Property: ident("p")}, Value: one()},
+			&AssignExpression{Target: &PropertyExpression{Left: two(), Property: ident("p")}, Value: two()},
+		},
+		{&YieldExpression{Value: one()}, &YieldExpression{Value: two()}},
+		{&AwaitExpression{Value: one()}, &AwaitExpression{Value: two()}},
+		{&TemplateLiteral{Parts: []Expression{one()}}, &TemplateLiteral{Parts: []Expression{two()}}},
+		{
+			&ListComprehension{Element: one(), Clause: ComprehensionClause{Variables: []*Identifier{ident("x")}, Iterable: one(), Condition: one()}},
+			&ListComprehension{Element: two(), Clause: ComprehensionClause{Variables: []*Identifier{ident("x")}, Iterable: two(), Condition: two()}},
+		},
+		{
+			&HashComprehension{Key: one(), Value: one(), Clause: ComprehensionClause{Variables: []*Identifier{ident("x")}, Iterable: one()}},
+			&HashComprehension{Key: two(), Value: two(), Clause: ComprehensionClause{Variables: []*Identifier{ident("x")}, Iterable: two()}},
+		},
+		{
+			&ClassStatement{Name: ident("A"), Methods: []*MethodDefinition{{Name: ident("m"), Function: &FunctionLiteral{Body: block(one())}}}},
+			&ClassStatement{Name: ident("A"), Methods: []*MethodDefinition{{Name: ident("m"), Function: &FunctionLiteral{Body: block(two())}}}},
+		},
+		{&MacroLiteral{Body: block(one())}, &MacroLiteral{Body: block(two())}},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+		assert.Equal(t, tt.expected, modified, "wrong modification of %T", tt.input)
+	}
+}
+
+func TestModifyLeavesOriginalUnchanged(t *testing.T) {
+	original := &InfixExpression{
+		Left:     &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"},
+		Operator: "+",
+		Right:    &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1},
+	}
+
+	modified := Modify(original, func(node Node) Node {
+		if _, ok := node.(*Identifier); ok {
+			return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "5"}, Value: 5}
+		}
+		return node
+	})
+
+	assert.Equal(t, "(x + 1)", original.String())
+	assert.Equal(t, "(5 + 1)", modified.String())
+}
+
+func TestModifyKeepsMisfitReplacements(t *testing.T) {
+	let := &LetStatement{Name: &Identifier{Value: "x"}, Value: &Identifier{Value: "y"}}
+
+	modified := Modify(let, func(node Node) Node {
+		if _, ok := node.(*Identifier); ok {
+			return &IntegerLiteral{Value: 5}
+		}
+		return node
+	}).(*LetStatement)
+
+	assert.Equal(t, "x", modified.Name.Value, "binding identifier replaced by an expression")
+	assert.Equal(t, &IntegerLiteral{Value: 5}, modified.Value)
+}
@@ -0,0 +1,188 @@
+package ast
+
+// Visitor has its Visit method called for each node encountered by Walk.
+// If the returned visitor w is not nil, Walk visits each of the children
+// of node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node in depth-first order, children in source order.
+// It starts by calling v.Visit(node). Identifiers in binding positions, such as let names,
+// function parameters and comprehension variables, are visited like any other node.
+// Optional children that are absent, e.g. the else block of an if, are skipped.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+	case *LetStatement:
+		walkIdentifier(v, n.Name)
+		walkExpression(v, n.Value)
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+	case *ImportStatement:
+		if n.Path != nil {
+			Walk(v, n.Path)
+		}
+		walkIdentifier(v, n.Alias)
+	case *ExportStatement:
+		if n.Declaration != nil {
+			Walk(v, n.Declaration)
+		}
+	case *StructStatement:
+		walkIdentifier(v, n.Name)
+		walkIdentifiers(v, n.Fields)
+	case *EnumStatement:
+		walkIdentifier(v, n.Name)
+		for _, variant := range n.Variants {
+			walkIdentifier(v, variant.Name)
+			walkIdentifiers(v, variant.Fields)
+		}
+	case *ClassStatement:
+		walkIdentifier(v, n.Name)
+		walkIdentifier(v, n.Superclass)
+		for _, method := range n.Methods {
+			walkIdentifier(v, method.Name)
+			if method.Function != nil {
+				Walk(v, method.Function)
+			}
+		}
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *RangeExpression:
+		walkExpression(v, n.Start)
+		walkExpression(v, n.End)
+	case *IfExpression:
+		walkExpression(v, n.Condition)
+		walkBlock(v, n.Consequence)
+		walkBlock(v, n.Alternative)
+	case *MatchExpression:
+		walkExpression(v, n.Subject)
+		for _, arm := range n.Arms {
+			walkExpression(v, arm.Pattern)
+			walkExpression(v, arm.Body)
+		}
+	case *FunctionLiteral:
+		walkIdentifiers(v, n.Parameters)
+		walkBlock(v, n.Body)
+	case *MacroLiteral:
+		walkIdentifiers(v, n.Parameters)
+		walkBlock(v, n.Body)
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		walkExpressions(v, n.Arguments)
+	case *TemplateLiteral:
+		walkExpressions(v, n.Parts)
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+	case *SetLiteral:
+		walkExpressions(v, n.Elements)
+	case *TupleLiteral:
+		walkExpressions(v, n.Elements)
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			walkExpression(v, pair.Key)
+			walkExpression(v, pair.Value)
+		}
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+	case *SliceExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Start)
+		walkExpression(v, n.End)
+		walkExpression(v, n.Step)
+	case *PropertyExpression:
+		walkExpression(v, n.Left)
+		walkIdentifier(v, n.Property)
+	case *AssignExpression:
+		if n.Target != nil {
+			Walk(v, n.Target)
+		}
+		walkExpression(v, n.Value)
+	case *YieldExpression:
+		walkExpression(v, n.Value)
+	case *AwaitExpression:
+		walkExpression(v, n.Value)
+	case *ListComprehension:
+		walkExpression(v, n.Element)
+		walkClause(v, &n.Clause)
+	case *HashComprehension:
+		walkExpression(v, n.Key)
+		walkExpression(v, n.Value)
+		walkClause(v, &n.Clause)
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, statements []Statement) {
+	for _, statement := range statements {
+		if statement != nil {
+			Walk(v, statement)
+		}
+	}
+}
+
+func walkExpressions(v Visitor, expressions []Expression) {
+	for _, expression := range expressions {
+		walkExpression(v, expression)
+	}
+}
+
+func walkExpression(v Visitor, expression Expression) {
+	if expression != nil {
+		Walk(v, expression)
+	}
+}
+
+func walkIdentifiers(v Visitor, identifiers []*Identifier) {
+	for _, identifier := range identifiers {
+		walkIdentifier(v, identifier)
+	}
+}
+
+func walkIdentifier(v Visitor, identifier *Identifier) {
+	if identifier != nil {
+		Walk(v, identifier)
+	}
+}
+
+func walkBlock(v Visitor, block *BlockStatement) {
+	if block != nil {
+		Walk(v, block)
+	}
+}
+
+func walkClause(v Visitor, clause *ComprehensionClause) {
+	walkIdentifiers(v, clause.Variables)
+	walkExpression(v, clause.Iterable)
+	walkExpression(v, clause.Condition)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node in depth-first order, calling f(node) for
+// each node. If f returns true, Inspect visits the children of node, followed by a call
+// of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
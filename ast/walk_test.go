@@ -0,0 +1,194 @@
+package ast_test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/lexer"
+	"gtihub.com/yudai2929/monkey-lang/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), "parser errors for %q", input)
+	return program
+}
+
+// allNodes is a program using every node type
+const allNodes = `
+import "lib.mk" as lib;
+export let answer = 42;
+const flag = !true;
+struct Point { x, y }
+enum Shape { Circle(r), Empty }
+class Dog extends Animal { speak() { self.sound = "woof"; yield self.sound } }
+let f = async fn(a, b) { return await g(a)[0]; };
+let m = macro(x) { quote(unquote(x)) };
+let h = {"k": null, 1: [1, 2][0:1]};
+let s = {1, (2, 3), 0..n, 0..=n};
+let c = [x * 2 for x in xs if x > 0];
+let d = {k: v for k, v in h};
+let t = "sum: ${a + b}";
+if (a) { b } else { c };
+match p { Point(x, _) => x, _ => -1 };
+`
+
+func TestInspectVisitsEveryNodeType(t *testing.T) {
+	program := parse(t, allNodes)
+
+	seen := map[string]bool{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node != nil {
+			seen[fmt.Sprintf("%T", node)] = true
+		}
+		return true
+	})
+
+	expected := []string{
+		"*ast.Program", "*ast.LetStatement", "*ast.Identifier", "*ast.ReturnStatement",
+		"*ast.ImportStatement", "*ast.ExportStatement", "*ast.StructStatement", "*ast.EnumStatement",
+		"*ast.ClassStatement", "*ast.ExpressionStatement", "*ast.IntegerLiteral", "*ast.PrefixExpression",
+		"*ast.InfixExpression", "*ast.RangeExpression", "*ast.Boolean", "*ast.NullLiteral",
+		"*ast.IfExpression", "*ast.MatchExpression", "*ast.BlockStatement", "*ast.FunctionLiteral",
+		"*ast.MacroLiteral", "*ast.CallExpression", "*ast.StringLiteral", "*ast.TemplateLiteral",
+		"*ast.ArrayLiteral", "*ast.IndexExpression", "*ast.PropertyExpression", "*ast.YieldExpression",
+		"*ast.AwaitExpression", "*ast.AssignExpression", "*ast.SliceExpression", "*ast.HashLiteral",
+		"*ast.ListComprehension", "*ast.HashComprehension", "*ast.SetLiteral", "*ast.TupleLiteral",
+	}
+
+	for _, typ := range expected {
+		assert.True(t, seen[typ], "%s not visited", typ)
+	}
+}
+
+func TestInspectOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"a + b * c", []string{"(a + (b * c))", "a", "(b * c)", "b", "c"}},
+		{`{"k": v, 1: w}`, []string{`{k:v, 1:w}`, "k", "v", "1", "w"}},
+		{"fn(x, y) { x }", []string{"fn(x, y) x", "x", "y", "x", "x"}},
+		{"[e for a, b in xs if c]", []string{"[e for a, b in xs if c]", "e", "a", "b", "xs", "c"}},
+		{"let x = 1;", []string{"x", "1"}},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+
+		var start ast.Node = program.Statements[0]
+		if stmt, ok := start.(*ast.ExpressionStatement); ok {
+			start = stmt.Expression
+		}
+
+		var visited []string
+		ast.Inspect(start, func(node ast.Node) bool {
+			if node == nil {
+				return false
+			}
+			if _, ok := node.(*ast.LetStatement); !ok {
+				if _, ok := node.(*ast.ExpressionStatement); !ok {
+					visited = append(visited, node.String())
+				}
+			}
+			return true
+		})
+
+		assert.Equal(t, tt.expected, visited, "wrong visiting order for %q", tt.input)
+	}
+}
+
+func TestInspectPrunes(t *testing.T) {
+	program := parse(t, "let f = fn(a) { a + b }; c + d;")
+
+	var identifiers []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FunctionLiteral); ok {
+			return false
+		}
+		if ident, ok := node.(*ast.Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"f", "c", "d"}, identifiers)
+}
+
+// depthVisitor records the depth of every node and checks each Visit(nil) closes a visited node
+type depthVisitor struct {
+	depth  *int
+	depths *[]int
+}
+
+func (v depthVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*v.depth--
+		return nil
+	}
+	*v.depths = append(*v.depths, *v.depth)
+	*v.depth++
+	return v
+}
+
+func TestWalkCallsVisitNilAfterChildren(t *testing.T) {
+	program := parse(t, "a + -b;")
+
+	depth := 0
+	var depths []int
+	ast.Walk(depthVisitor{depth: &depth, depths: &depths}, program)
+
+	assert.Equal(t, 0, depth, "Visit(nil) calls do not balance visited nodes")
+	// Program, ExpressionStatement, Infix, a, Prefix, b
+	assert.Equal(t, []int{0, 1, 2, 3, 3, 4}, depths)
+}
+
+func TestRewrite(t *testing.T) {
+	program := parse(t, "let double = fn(x) { x * 2 }; double(x);")
+
+	renamed := ast.Rewrite(program, func(node ast.Node) (ast.Node, bool) {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "x" {
+			return &ast.Identifier{Token: ident.Token, Value: "n"}, false
+		}
+		return node, true
+	})
+
+	assert.Equal(t, "let double = fn(n) (n * 2);double(n)", renamed.String())
+	assert.Equal(t, "let double = fn(x) (x * 2);double(x)", program.String(), "Rewrite changed the original tree")
+}
+
+func TestRewriteStopsAtReplacements(t *testing.T) {
+	program := parse(t, "f(g(1));")
+
+	calls := 0
+	rewritten := ast.Rewrite(program, func(node ast.Node) (ast.Node, bool) {
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node, true
+		}
+		calls++
+		return call.Arguments[0], false
+	})
+
+	assert.Equal(t, 1, calls, "Rewrite descended into a replacement")
+	assert.Equal(t, "g(1)", rewritten.String())
+}
+
+func TestRewriteRemovesStatements(t *testing.T) {
+	program := parse(t, `let a = 1; puts("debug"); let b = 2; fn() { puts("x"); a };`)
+
+	withoutPuts := ast.Rewrite(program, func(node ast.Node) (ast.Node, bool) {
+		if stmt, ok := node.(*ast.ExpressionStatement); ok {
+			if call, ok := stmt.Expression.(*ast.CallExpression); ok && call.Function.String() == "puts" {
+				return nil, false
+			}
+		}
+		return node, true
+	})
+
+	assert.Equal(t, "let a = 1;let b = 2;fn() a", withoutPuts.String())
+	assert.Len(t, program.Statements, 4, "Rewrite changed the original tree")
+}
@@ -0,0 +1,289 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"sync"
+	"time"
+)
+
+// asyncBuiltins create and combine futures
+var asyncBuiltins = map[string]*object.Builtin{
+	"sleep": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			ms, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("argument to `sleep` must be INTEGER, got %s", args[0].Type())
+			}
+
+			return object.FutureOf(func() object.Object {
+				time.Sleep(time.Duration(ms.Value) * time.Millisecond)
+				return NULL
+			})
+		},
+	},
+	"all": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `all` must be ARRAY, got %s", args[0].Type())
+			}
+
+			return allFutures(arr.Elements)
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range asyncBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// allFutures returns a future resolved with the values of elements once every future among them
+// is resolved, or with the first error
+func allFutures(elements []object.Object) *object.Future {
+	result := object.NewFuture()
+	values := make([]object.Object, len(elements))
+
+	var mu sync.Mutex
+	pending := len(elements)
+	settle := func(i int, value object.Object) {
+		if isError(value) {
+			result.Resolve(value)
+			return
+		}
+
+		mu.Lock()
+		values[i] = value
+		pending--
+		finished := pending == 0
+		mu.Unlock()
+
+		if finished {
+			result.Resolve(&object.Array{Elements: values})
+		}
+	}
+
+	if pending == 0 {
+		result.Resolve(&object.Array{Elements: values})
+	}
+
+	for i, el := range elements {
+		future, ok := el.(*object.Future)
+		if !ok {
+			settle(i, el)
+			continue
+		}
+
+		i := i
+		future.OnResolve(func() {
+			value, _ := future.Result()
+			settle(i, value)
+		})
+	}
+
+	return result
+}
+
+// eventLoop runs the async functions called by one top-level program, one step at a time.
+// A step lasts until the body of an async function awaits a pending future or returns, so
+// async bodies never run in parallel with each other. Goroutines resolving futures only post
+// to the queue; the loop is driven by whoever awaits outside an async function, and by Eval
+// once the program is done.
+type eventLoop struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []func()
+	running bool // a goroutine is running a queued task
+	pending int  // async calls that have not returned yet
+}
+
+func newEventLoop() *eventLoop {
+	l := &eventLoop{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// post queues task to run on the loop. It may be called from any goroutine.
+func (l *eventLoop) post(task func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.queue = append(l.queue, task)
+	l.cond.Broadcast()
+}
+
+// wake lets the goroutines waiting in runUntil check their condition again
+func (l *eventLoop) wake() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cond.Broadcast()
+}
+
+// runUntil runs queued tasks until done reports true, waiting while the loop is idle.
+// Several goroutines may run it at once, e.g. spawned functions awaiting outside async
+// functions; they take turns, so only one task runs at a time. done is called with l.mu held.
+func (l *eventLoop) runUntil(done func() bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for !done() {
+		if l.running || len(l.queue) == 0 {
+			l.cond.Wait()
+			continue
+		}
+
+		task := l.queue[0]
+		l.queue = l.queue[1:]
+		l.running = true
+		l.mu.Unlock()
+
+		task()
+
+		l.mu.Lock()
+		l.running = false
+		l.cond.Broadcast()
+	}
+}
+
+// Await runs the loop until future is resolved. It serves awaits outside async functions.
+func (l *eventLoop) Await(future *object.Future) object.Object {
+	future.OnResolve(l.wake)
+	l.runUntil(func() bool {
+		_, ok := future.Result()
+		return ok
+	})
+
+	value, _ := future.Result()
+	return value
+}
+
+// drain runs the loop until every async call made on it has returned,
+// so that calls nobody awaited still run to completion
+func (l *eventLoop) drain() {
+	l.runUntil(func() bool { return l.pending == 0 })
+}
+
+// start queues the first step of the async call co, whose body is run by body
+func (l *eventLoop) start(co *asyncCoroutine, body func()) {
+	l.mu.Lock()
+	l.pending++
+	l.mu.Unlock()
+
+	l.post(func() {
+		go func() {
+			<-co.resume
+			defer func() {
+				l.mu.Lock()
+				l.pending--
+				l.mu.Unlock()
+				co.parked <- struct{}{}
+			}()
+			body()
+		}()
+		co.step()
+	})
+}
+
+// loopOf returns the event loop running the code evaluated in env, or nil outside any program
+func loopOf(env *object.Environment) *eventLoop {
+	switch awaiter := env.Awaiter().(type) {
+	case *eventLoop:
+		return awaiter
+	case *asyncCoroutine:
+		return awaiter.loop
+	default:
+		return nil
+	}
+}
+
+// asyncCoroutine runs the body of an async function on its own goroutine, in steps driven by its loop
+type asyncCoroutine struct {
+	loop   *eventLoop
+	resume chan struct{}
+	parked chan struct{}
+}
+
+// step hands control to the coroutine and waits until it parks again or finishes
+func (c *asyncCoroutine) step() {
+	c.resume <- struct{}{}
+	<-c.parked
+}
+
+// Await parks the coroutine until future is resolved, letting the loop run other tasks meanwhile.
+// The resolution of the future, possibly on a goroutine of the host, posts the next step to the loop.
+func (c *asyncCoroutine) Await(future *object.Future) object.Object {
+	future.OnResolve(func() { c.loop.post(c.step) })
+
+	c.parked <- struct{}{}
+	<-c.resume
+
+	value, _ := future.Result()
+	return value
+}
+
+// startAsync calls the async function fn with args. Its body starts on a later turn of the loop
+// of the program that defined fn. An async function without one, e.g. exported by a module whose
+// evaluation is over, runs on a loop of its own that is drained before the call returns.
+func startAsync(fn *object.Function, args []object.Object) object.Object {
+	loop := loopOf(fn.Env)
+	if loop == nil {
+		loop = newEventLoop()
+		defer loop.drain()
+	}
+
+	future := object.NewFuture()
+	env := extendFunctionEnv(fn, args)
+	co := &asyncCoroutine{loop: loop, resume: make(chan struct{}), parked: make(chan struct{})}
+	env.SetAwaiter(co)
+
+	loop.start(co, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				future.Resolve(newError("async function failed: %v", r))
+			}
+		}()
+
+		result := unwrapReturnValue(Eval(fn.Body, env))
+		// Closures escaping the body must not park a coroutine that has finished.
+		env.SetAwaiter(nil)
+		future.Resolve(result)
+	})
+
+	return future
+}
+
+// evalAwaitExpression returns the value of a future, or any other value as is.
+// Inside an async function a pending future parks the function; elsewhere the
+// event loop runs until the future is resolved.
+func evalAwaitExpression(node *ast.AwaitExpression, env *object.Environment) object.Object {
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	future, ok := value.(*object.Future)
+	if !ok {
+		return value
+	}
+
+	if result, ok := future.Result(); ok {
+		return result
+	}
+
+	if awaiter := env.Awaiter(); awaiter != nil {
+		return awaiter.Await(future)
+	}
+
+	return future.Wait()
+}
@@ -1,6 +1,10 @@
 package evalutor
 
-import "gtihub.com/yudai2929/monkey-lang/object"
+import (
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"math"
+	"strings"
+)
 
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
@@ -14,6 +18,23 @@ var builtins = map[string]*object.Builtin{
 				return &object.Integer{Value: int64(len(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Tuple:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Set:
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Range:
+				if arg.Len() > math.MaxInt64 {
+					return newError("length of %s does not fit in INTEGER", arg.Inspect())
+				}
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Generator:
+				elements, err := elementsOf("len", arg)
+				if err != nil {
+					return err
+				}
+				return &object.Integer{Value: int64(len(elements))}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
@@ -25,6 +46,14 @@ var builtins = map[string]*object.Builtin{
 				return newError("wrong number of arguments. got=%d, want=1", len(args))
 			}
 
+			if gen, ok := args[0].(*object.Generator); ok {
+				value, ok := gen.Next()
+				if !ok {
+					return NULL
+				}
+				return value
+			}
+
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
 			}
@@ -62,6 +91,14 @@ var builtins = map[string]*object.Builtin{
 				return newError("wrong number of arguments. got=%d, want=1", len(args))
 			}
 
+			// A generator cannot be copied, so rest advances it past its next value and returns it
+			if gen, ok := args[0].(*object.Generator); ok {
+				if value, ok := gen.Next(); ok && isError(value) {
+					return value
+				}
+				return gen
+			}
+
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to `rest` must be ARRAY, got %s", args[0].Type())
 			}
@@ -97,13 +134,127 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
-	"puts": &object.Builtin{
+	"contains": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			switch container := args[0].(type) {
+			case *object.String:
+				sub, ok := args[1].(*object.String)
+				if !ok {
+					return newError("argument to `contains` must be STRING, got %s", args[1].Type())
+				}
+				return nativeBoolToBooleanObject(strings.Contains(container.Value, sub.Value))
+			case *object.Array, *object.Tuple:
+				elements, _ := sequenceOf(container)
+				for _, el := range elements {
+					if object.Equal(el, args[1]) {
+						return TRUE
+					}
+				}
+				return FALSE
+			case *object.Set:
+				element, ok := object.AsHashable(args[1])
+				return nativeBoolToBooleanObject(ok && container.Contains(element))
+			case *object.Range:
+				n, ok := args[1].(*object.Integer)
+				return nativeBoolToBooleanObject(ok && container.Contains(n.Value))
+			case *object.Hash:
+				key, ok := object.AsHashable(args[1])
+				if !ok {
+					return newError("unusable as hash key: %s", args[1].Type())
+				}
+				_, ok = container.Get(key)
+				return nativeBoolToBooleanObject(ok)
+			default:
+				return newError("argument to `contains` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"load": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return newError("load not supported: no module loader configured")
+		},
+	},
+	"type": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Struct:
+				return &object.String{Value: arg.Definition.Name}
+			case *object.Variant:
+				return &object.String{Value: arg.Definition.Enum.Name}
+			case *object.Instance:
+				return &object.String{Value: arg.Class.Name}
+			default:
+				return &object.String{Value: string(arg.Type())}
+			}
+		},
+	},
+	"tag": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			variant, ok := args[0].(*object.Variant)
+			if !ok {
+				return newError("argument to `tag` must be VARIANT, got %s", args[0].Type())
+			}
+
+			return &object.String{Value: variant.Definition.Name}
+		},
+	},
+	"instance_of": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			class, ok := args[1].(*object.Class)
+			if !ok {
+				return newError("argument to `instance_of` must be CLASS, got %s", args[1].Type())
+			}
+
+			instance, ok := args[0].(*object.Instance)
+			return nativeBoolToBooleanObject(ok && instance.Class.IsSubclassOf(class))
+		},
+	},
+}
+
+// str and puts honor __str__ handlers, which run Monkey code and so reach back into builtins.
+// They are registered by init rather than in the literal above to avoid an initialization cycle.
+func init() {
+	builtins["str"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			str, err := stringify(args[0])
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: str}
+		},
+	}
+	builtins["puts"] = &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
-				println(arg.Inspect())
+				str, err := stringify(arg)
+				if err != nil {
+					return err
+				}
+				println(str)
 			}
 
 			return NULL
 		},
-	},
+	}
 }
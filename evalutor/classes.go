@@ -0,0 +1,124 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+func evalClassStatement(node *ast.ClassStatement, env *object.Environment) object.Object {
+	class := &object.Class{Name: node.Name.Value, Methods: make(map[string]*object.Function)}
+
+	if node.Superclass != nil {
+		superclass := Eval(node.Superclass, env)
+		if isError(superclass) {
+			return superclass
+		}
+
+		sc, ok := superclass.(*object.Class)
+		if !ok {
+			return newError("superclass of %s must be CLASS, got %s", class.Name, superclass.Type())
+		}
+		class.Superclass = sc
+	}
+
+	for _, method := range node.Methods {
+		class.Methods[method.Name.Value] = &object.Function{
+			Parameters:  method.Function.Parameters,
+			Body:        method.Function.Body,
+			Env:         env,
+			IsGenerator: method.Function.IsGenerator,
+		}
+	}
+
+	if err := env.Set(class.Name, class); err != nil {
+		return newError("%s", err)
+	}
+
+	return nil
+}
+
+// newInstance creates an instance of class and runs init, the constructor, with args
+func newInstance(class *object.Class, args []object.Object) object.Object {
+	instance := object.NewInstance(class)
+
+	constructor, ok := instanceMember(instance, "init")
+	if !ok {
+		if len(args) != 0 {
+			return newError("wrong number of arguments. got=%d, want=0", len(args))
+		}
+		return instance
+	}
+
+	if result := applyFunction(constructor, args); isError(result) {
+		return result
+	}
+
+	return instance
+}
+
+// instanceMember returns the field name of instance, or else the method name bound to it
+func instanceMember(instance *object.Instance, name string) (object.Object, bool) {
+	if value, ok := instance.Get(name); ok {
+		return value, true
+	}
+
+	method, class, ok := instance.Class.Method(name)
+	if !ok {
+		return nil, false
+	}
+
+	return &object.BoundMethod{Name: name, Receiver: instance, Method: method, Class: class}, true
+}
+
+// superMethod returns the method name of the superclass bound to the current receiver
+func superMethod(super *object.Super, name string) (object.Object, bool) {
+	method, class, ok := super.Class.Method(name)
+	if !ok {
+		return nil, false
+	}
+
+	return &object.BoundMethod{Name: name, Receiver: super.Receiver, Method: method, Class: class}, true
+}
+
+// applyMethod calls a bound method with self, and super when its class has a superclass, in scope
+func applyMethod(method *object.BoundMethod, args []object.Object) object.Object {
+	if len(args) != len(method.Method.Parameters) {
+		return newError("wrong number of arguments to %s. got=%d, want=%d", method.Inspect(), len(args), len(method.Method.Parameters))
+	}
+
+	methodEnv := object.NewEnclosedEnvironment(method.Method.Env)
+	methodEnv.Set("self", method.Receiver)
+	if method.Class.Superclass != nil {
+		methodEnv.Set("super", &object.Super{Receiver: method.Receiver, Class: method.Class.Superclass})
+	}
+
+	fn := &object.Function{
+		Parameters:  method.Method.Parameters,
+		Body:        method.Method.Body,
+		Env:         methodEnv,
+		IsGenerator: method.Method.IsGenerator,
+	}
+
+	return applyFunction(fn, args)
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	target := Eval(node.Target.Left, env)
+	if isError(target) {
+		return target
+	}
+
+	instance, ok := target.(*object.Instance)
+	if !ok {
+		return newError("cannot assign property %s of %s", node.Target.Property.Value, target.Type())
+	}
+
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	instance.Set(node.Target.Property.Value, value)
+
+	return value
+}
@@ -0,0 +1,185 @@
+package evalutor
+
+import "gtihub.com/yudai2929/monkey-lang/object"
+
+// collectionBuiltins work on arrays and hashes. Every function takes the collection as its first argument.
+// map, filter, reduce and sum also take tuples, sets and ranges, which yield an array.
+// map and filter stay lazy on generators; reduce and sum run them to the end.
+var collectionBuiltins = map[string]*object.Builtin{
+	"map": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			if gen, ok := args[0].(*object.Generator); ok {
+				return mapGenerator(gen, args[1])
+			}
+
+			values, err := elementsOf("map", args[0])
+			if err != nil {
+				return err
+			}
+
+			elements := make([]object.Object, len(values))
+			for i, el := range values {
+				mapped := applyFunction(args[1], []object.Object{el})
+				if isError(mapped) {
+					return mapped
+				}
+				elements[i] = mapped
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"filter": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			if gen, ok := args[0].(*object.Generator); ok {
+				return filterGenerator(gen, args[1])
+			}
+
+			values, err := elementsOf("filter", args[0])
+			if err != nil {
+				return err
+			}
+
+			var elements []object.Object
+			for _, el := range values {
+				keep := applyFunction(args[1], []object.Object{el})
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					elements = append(elements, el)
+				}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"reduce": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+
+			elements, err := elementsOf("reduce", args[0])
+			if err != nil {
+				return err
+			}
+
+			acc := args[1]
+			for _, el := range elements {
+				acc = applyFunction(args[2], []object.Object{acc, el})
+				if isError(acc) {
+					return acc
+				}
+			}
+
+			return acc
+		},
+	},
+	"sum": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			elements, err := elementsOf("sum", args[0])
+			if err != nil {
+				return err
+			}
+
+			var total int64
+			for _, el := range elements {
+				integer, ok := el.(*object.Integer)
+				if !ok {
+					return newError("elements of `sum` must be INTEGER, got %s", el.Type())
+				}
+				total += integer.Value
+			}
+
+			return &object.Integer{Value: total}
+		},
+	},
+	"keys": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `keys` must be HASH, got %s", args[0].Type())
+			}
+
+			var elements []object.Object
+			for _, pair := range hash.Pairs() {
+				elements = append(elements, pair.Key)
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"values": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `values` must be HASH, got %s", args[0].Type())
+			}
+
+			var elements []object.Object
+			for _, pair := range hash.Pairs() {
+				elements = append(elements, pair.Value)
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range collectionBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// mapGenerator returns a generator applying fn to each value of gen as it is requested
+func mapGenerator(gen *object.Generator, fn object.Object) *object.Generator {
+	return &object.Generator{Next: func() (object.Object, bool) {
+		value, ok := gen.Next()
+		if !ok || isError(value) {
+			return value, ok
+		}
+		return applyFunction(fn, []object.Object{value}), true
+	}, Stop: gen.Close}
+}
+
+// filterGenerator returns a generator producing the values of gen for which fn is truthy
+func filterGenerator(gen *object.Generator, fn object.Object) *object.Generator {
+	return &object.Generator{Next: func() (object.Object, bool) {
+		for {
+			value, ok := gen.Next()
+			if !ok || isError(value) {
+				return value, ok
+			}
+
+			keep := applyFunction(fn, []object.Object{value})
+			if isError(keep) {
+				return keep, true
+			}
+			if isTruthy(keep) {
+				return value, true
+			}
+		}
+	}, Stop: gen.Close}
+}
@@ -0,0 +1,148 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+func evalListComprehension(node *ast.ListComprehension, env *object.Environment) object.Object {
+	elements := []object.Object{}
+
+	err := comprehend(&node.Clause, env, func(scope *object.Environment) object.Object {
+		element := Eval(node.Element, scope)
+		if isError(element) {
+			return element
+		}
+		elements = append(elements, element)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func evalHashComprehension(node *ast.HashComprehension, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	err := comprehend(&node.Clause, env, func(scope *object.Environment) object.Object {
+		key := Eval(node.Key, scope)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := object.AsHashable(key)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(node.Value, scope)
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return hash
+}
+
+// comprehend calls body for every element of the clause's iterable that satisfies its condition.
+// Each element gets its own environment enclosed by env binding the loop variables, so closures
+// created in the body keep the values of their iteration. body returns an error to stop, or nil.
+func comprehend(clause *ast.ComprehensionClause, env *object.Environment, body func(*object.Environment) object.Object) object.Object {
+	iterable := Eval(clause.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	next, ok := comprehensionIterator(iterable, len(clause.Variables))
+	if !ok {
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	for {
+		value, ok := next()
+		if !ok {
+			return nil
+		}
+		if isError(value) {
+			return value
+		}
+
+		scope := object.NewEnclosedEnvironment(env)
+		if err := bindLoopVariables(clause.Variables, value, scope); err != nil {
+			return err
+		}
+
+		if clause.Condition != nil {
+			condition := Eval(clause.Condition, scope)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				continue
+			}
+		}
+
+		if err := body(scope); err != nil {
+			return err
+		}
+	}
+}
+
+// comprehensionIterator returns a function producing the elements of iterable.
+// A hash produces its keys for one loop variable and (key, value) tuples for two.
+func comprehensionIterator(iterable object.Object, variables int) (func() (object.Object, bool), bool) {
+	hash, ok := iterable.(*object.Hash)
+	if !ok {
+		return iterate(iterable)
+	}
+
+	pairs := hash.Pairs()
+	i := 0
+	return func() (object.Object, bool) {
+		if i >= len(pairs) {
+			return nil, false
+		}
+		pair := pairs[i]
+		i++
+
+		if variables == 1 {
+			return pair.Key, true
+		}
+		return &object.Tuple{Elements: []object.Object{pair.Key, pair.Value}}, true
+	}, true
+}
+
+// bindLoopVariables binds value to a single loop variable, or unpacks a tuple or array into two
+func bindLoopVariables(variables []*ast.Identifier, value object.Object, scope *object.Environment) object.Object {
+	if len(variables) == 1 {
+		scope.Set(variables[0].Value, value)
+		return nil
+	}
+
+	var values []object.Object
+	switch value := value.(type) {
+	case *object.Tuple:
+		values = value.Elements
+	case *object.Array:
+		values = value.Elements
+	default:
+		return newError("cannot unpack %s into %d loop variables", value.Type(), len(variables))
+	}
+
+	if len(values) != len(variables) {
+		return newError("wrong number of values to unpack. got=%d, want=%d", len(values), len(variables))
+	}
+
+	for i, variable := range variables {
+		scope.Set(variable.Value, values[i])
+	}
+	return nil
+}
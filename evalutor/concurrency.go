@@ -0,0 +1,214 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"reflect"
+)
+
+// concurrencyBuiltins start functions on goroutines and let them communicate.
+// Spawned functions share the environments they close over; bindings, instance fields
+// and the module loader are safe for that, values themselves are immutable.
+var concurrencyBuiltins = map[string]*object.Builtin{
+	"spawn": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, want at least 1", len(args))
+			}
+
+			task := object.NewTask()
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						task.Finish(newError("spawned function failed: %v", r))
+					}
+				}()
+				task.Finish(applyFunction(args[0], args[1:]))
+			}()
+
+			return task
+		},
+	},
+	"wait": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Task:
+				return arg.Wait()
+			case *object.WaitGroup:
+				arg.Wait()
+				return NULL
+			default:
+				return newError("argument to `wait` must be TASK or WAITGROUP, got %s", args[0].Type())
+			}
+		},
+	},
+	"channel": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			if len(args) == 0 {
+				return object.NewChannel(0)
+			}
+
+			capacity, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("argument to `channel` must be INTEGER, got %s", args[0].Type())
+			}
+			if capacity.Value < 0 {
+				return newError("negative channel capacity: %d", capacity.Value)
+			}
+
+			return object.NewChannel(int(capacity.Value))
+		},
+	},
+	"send": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			ch, ok := args[0].(*object.Channel)
+			if !ok {
+				return newError("argument to `send` must be CHANNEL, got %s", args[0].Type())
+			}
+
+			if !ch.Send(args[1]) {
+				return newError("send on closed channel")
+			}
+
+			return NULL
+		},
+	},
+	"receive": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			ch, ok := args[0].(*object.Channel)
+			if !ok {
+				return newError("argument to `receive` must be CHANNEL, got %s", args[0].Type())
+			}
+
+			value, ok := ch.Receive()
+			if !ok {
+				return NULL
+			}
+
+			return value
+		},
+	},
+	"close": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Channel:
+				if !arg.Close() {
+					return newError("close of closed channel")
+				}
+			case *object.Generator:
+				arg.Close()
+			default:
+				return newError("argument to `close` must be CHANNEL or GENERATOR, got %s", args[0].Type())
+			}
+
+			return NULL
+		},
+	},
+	"select": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `select` must be ARRAY, got %s", args[0].Type())
+			}
+			if len(arr.Elements) == 0 {
+				return newError("select needs at least one channel")
+			}
+
+			cases := make([]reflect.SelectCase, len(arr.Elements))
+			for i, el := range arr.Elements {
+				ch, ok := el.(*object.Channel)
+				if !ok {
+					return newError("elements of `select` must be CHANNEL, got %s", el.Type())
+				}
+				cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch.Chan())}
+			}
+
+			chosen, value, ok := reflect.Select(cases)
+			received := object.Object(NULL)
+			if ok {
+				received = value.Interface().(object.Object)
+			}
+
+			return &object.Array{Elements: []object.Object{&object.Integer{Value: int64(chosen)}, received}}
+		},
+	},
+	"waitgroup": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &object.WaitGroup{}
+		},
+	},
+	"add": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			wg, ok := args[0].(*object.WaitGroup)
+			if !ok {
+				return newError("argument to `add` must be WAITGROUP, got %s", args[0].Type())
+			}
+
+			delta, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `add` must be INTEGER, got %s", args[1].Type())
+			}
+
+			if !wg.Add(delta.Value) {
+				return newError("negative waitgroup counter")
+			}
+
+			return NULL
+		},
+	},
+	"done": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			wg, ok := args[0].(*object.WaitGroup)
+			if !ok {
+				return newError("argument to `done` must be WAITGROUP, got %s", args[0].Type())
+			}
+
+			if !wg.Add(-1) {
+				return newError("negative waitgroup counter")
+			}
+
+			return NULL
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range concurrencyBuiltins {
+		builtins[name] = builtin
+	}
+}
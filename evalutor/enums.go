@@ -0,0 +1,73 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+// evalEnumStatement binds the enum under its name and every variant under its own name,
+// so both Result.Ok(1) and Ok(1) construct the same value.
+func evalEnumStatement(node *ast.EnumStatement, env *object.Environment) object.Object {
+	enum := &object.EnumType{Name: node.Name.Value}
+	for _, v := range node.Variants {
+		variant := &object.VariantType{Enum: enum, Name: v.Name.Value}
+		for _, field := range v.Fields {
+			variant.Fields = append(variant.Fields, field.Value)
+		}
+		if len(variant.Fields) == 0 {
+			variant.Unit = &object.Variant{Definition: variant}
+		}
+		enum.Variants = append(enum.Variants, variant)
+	}
+
+	if err := env.Set(enum.Name, enum); err != nil {
+		return newError("%s", err)
+	}
+
+	for _, variant := range enum.Variants {
+		if err := env.Set(variant.Name, variantValue(variant)); err != nil {
+			return newError("%s", err)
+		}
+	}
+
+	return nil
+}
+
+// variantValue returns the value a variant name refers to:
+// the variant itself when it has no fields, its constructor otherwise
+func variantValue(variant *object.VariantType) object.Object {
+	if variant.Unit != nil {
+		return variant.Unit
+	}
+	return variant
+}
+
+// newVariant constructs a value of variant from positional field values
+func newVariant(variant *object.VariantType, args []object.Object) object.Object {
+	if len(args) != len(variant.Fields) {
+		return newError("wrong number of fields for %s. got=%d, want=%d", variant.Inspect(), len(args), len(variant.Fields))
+	}
+
+	values := make([]object.Object, len(args))
+	copy(values, args)
+
+	return &object.Variant{Definition: variant, Values: values}
+}
+
+// enumVariant returns the variant name of enum, failing on unknown variants
+func enumVariant(enum *object.EnumType, name string) object.Object {
+	variant, ok := enum.Variant(name)
+	if !ok {
+		return newError("unknown variant %s for enum %s", name, enum.Name)
+	}
+	return variantValue(variant)
+}
+
+// variantField returns the field name of v, failing on unknown fields
+func variantField(v *object.Variant, name string) object.Object {
+	value, ok := v.Field(name)
+	if !ok {
+		return newError("unknown field %s for %s", name, v.Definition.Inspect())
+	}
+	return value
+}
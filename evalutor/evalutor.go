@@ -1,6 +1,7 @@
 package evalutor
 
 import (
+	"bytes"
 	"fmt"
 	"gtihub.com/yudai2929/monkey-lang/ast"
 	"gtihub.com/yudai2929/monkey-lang/object"
@@ -22,8 +23,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Integer{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return NULL
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.TemplateLiteral:
+		return evalTemplateLiteral(node, env)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -35,6 +40,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(left) {
 			return left
 		}
+		if node.Operator == "|>" {
+			return evalPipelineExpression(left, node.Right, env)
+		}
+		if node.Operator == "??" {
+			if left != NULL {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
@@ -44,6 +58,14 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.YieldExpression:
+		return evalYieldExpression(node, env)
+	case *ast.AwaitExpression:
+		return evalAwaitExpression(node, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
@@ -55,46 +77,73 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if err := bind(env, node, val); err != nil {
+			return err
+		}
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return evalExportStatement(node, env)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
+	case *ast.ClassStatement:
+		return evalClassStatement(node, env)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
-	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-		return applyFunction(function, args)
+		return &object.Function{Parameters: params, Body: body, Env: env, IsGenerator: node.IsGenerator, IsAsync: node.IsAsync}
+	case *ast.CallExpression, *ast.IndexExpression, *ast.SliceExpression, *ast.PropertyExpression:
+		value, _ := evalChain(node.(ast.Expression), env)
+		return value
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-		return evalIndexExpression(left, index)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+	case *ast.RangeExpression:
+		return evalRangeExpression(node, env)
+	case *ast.MacroLiteral:
+		return newError("macro must be defined by a top-level let statement")
+	case *ast.ListComprehension:
+		return evalListComprehension(node, env)
+	case *ast.HashComprehension:
+		return evalHashComprehension(node, env)
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
+	case *ast.TupleLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Tuple{Elements: elements}
 	}
 	return nil
 }
 
+// evalProgram evaluates the statements of program. The outermost program evaluated in an
+// environment gets an event loop for its async calls, which is drained before evalProgram returns.
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	if env.Awaiter() != nil {
+		return evalStatements(program, env)
+	}
+
+	loop := newEventLoop()
+	env.SetAwaiter(loop)
+	defer env.SetAwaiter(nil)
+
+	result := evalStatements(program, env)
+	loop.drain()
+	return result
+}
+
+func evalStatements(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range program.Statements {
@@ -140,6 +189,9 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
+		if result, ok := callOperator(right, "__neg__"); ok {
+			return result
+		}
 		return evalMinusPrefixOperatorExpression(right)
 	default:
 		return NULL
@@ -169,15 +221,23 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 }
 
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	if result, ok := evalOverloadedInfixExpression(operator, left, right); ok {
+		return result
+	}
+
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case operator == "*" && left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ:
+		return repeatString(left.(*object.String), right.(*object.Integer))
+	case operator == "*" && left.Type() == object.INTEGER_OBJ && right.Type() == object.STRING_OBJ:
+		return repeatString(right.(*object.String), left.(*object.Integer))
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -212,14 +272,16 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 }
 
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
 
 	switch operator {
 	case "+":
 		return &object.String{Value: leftVal + rightVal}
-		// TODO: Implement other operators
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -229,6 +291,24 @@ func evalStringInfixExpression(operator string, left object.Object, right object
 	}
 }
 
+func evalTemplateLiteral(node *ast.TemplateLiteral, env *object.Environment) object.Object {
+	var out bytes.Buffer
+
+	for _, part := range node.Parts {
+		evaluated := Eval(part, env)
+		if isError(evaluated) {
+			return evaluated
+		}
+		str, err := stringify(evaluated)
+		if err != nil {
+			return err
+		}
+		out.WriteString(str)
+	}
+
+	return &object.String{Value: out.String()}
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
@@ -267,6 +347,20 @@ func isError(obj object.Object) bool {
 	return false
 }
 
+// bind stores the value of a let or const statement in env
+func bind(env *object.Environment, node *ast.LetStatement, val object.Object) *object.Error {
+	set := env.Set
+	if node.IsConst() {
+		set = env.SetConst
+	}
+
+	if err := set(node.Name.Value, val); err != nil {
+		return newError("%s", err)
+	}
+
+	return nil
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	if val, ok := env.Get(node.Value); ok {
 		return val
@@ -294,14 +388,111 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
+// evalCallExpression evaluates a call. piped holds the arguments supplied by a
+// pipeline, which are passed ahead of the call's own arguments. It reports whether an
+// optional link of the chain the call belongs to skipped it.
+func evalCallExpression(node *ast.CallExpression, piped []object.Object, env *object.Environment) (object.Object, bool) {
+	if property, ok := node.Function.(*ast.PropertyExpression); ok {
+		return evalMethodCall(property, piped, node.Arguments, env)
+	}
+
+	if isSpecialForm(node, "quote") {
+		return evalQuote(node, env), false
+	}
+	if isSpecialForm(node, "unquote") {
+		return newError("unquote outside quote"), false
+	}
+
+	function, skipped := evalChainLeft(node.Function, false, env)
+	if skipped || isError(function) {
+		return function, skipped
+	}
+
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0], false
+	}
+
+	return applyFunction(function, append(piped, args...)), false
+}
+
+// evalChain evaluates a link of a chain of property, index, slice and call expressions such as
+// h?.a[0].f(). It reports whether an optional link found null: the rest of the chain is then
+// skipped and yields null, so h?.a.b is null rather than an error when h is null.
+func evalChain(node ast.Expression, env *object.Environment) (object.Object, bool) {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		return evalCallExpression(node, nil, env)
+	case *ast.IndexExpression:
+		left, skipped := evalChainLeft(node.Left, node.Optional, env)
+		if skipped || isError(left) {
+			return left, skipped
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index, false
+		}
+		return evalIndexExpression(left, index), false
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.PropertyExpression:
+		return evalPropertyExpression(node, env)
+	default:
+		return Eval(node, env), false
+	}
+}
+
+// evalChainLeft evaluates the left side of a link, reporting whether the link is skipped:
+// either it is optional and left is null, or a link further left was skipped already
+func evalChainLeft(left ast.Expression, optional bool, env *object.Environment) (object.Object, bool) {
+	value, skipped := evalChain(left, env)
+	if skipped || (optional && value == NULL) {
+		return NULL, true
+	}
+	return value, false
+}
+
+// evalPipelineExpression evaluates left |> right. A call on the right receives left as its
+// first argument; any other expression must evaluate to a function, which is called with left.
+func evalPipelineExpression(left object.Object, right ast.Expression, env *object.Environment) object.Object {
+	if call, ok := right.(*ast.CallExpression); ok {
+		result, _ := evalCallExpression(call, []object.Object{left}, env)
+		return result
+	}
+
+	function := Eval(right, env)
+	if isError(function) {
+		return function
+	}
+
+	return applyFunction(function, []object.Object{left})
+}
+
 func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments. got=%d, want=%d", len(args), len(fn.Parameters))
+		}
+		if fn.IsAsync {
+			return startAsync(fn, args)
+		}
+		if fn.IsGenerator {
+			return newGenerator(fn, args)
+		}
 		extendedEnv := extendFunctionEnv(fn, args)
 		evaluated := Eval(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
 		return fn.Fn(args...)
+	case *object.StructType:
+		return newStruct(fn, args)
+	case *object.VariantType:
+		return newVariant(fn, args)
+	case *object.Class:
+		return newInstance(fn, args)
+	case *object.BoundMethod:
+		return applyMethod(fn, args)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
@@ -324,67 +515,282 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
+// evalIndexExpression returns left[index]. An __index__ handler is consulted only for indices
+// the value cannot resolve itself, i.e. keys missing from a hash or any index on an instance,
+// so the handler can read the entries of its own hash with self[key].
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalTupleIndexExpression(left, index)
+	case left.Type() == object.RANGE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalRangeIndexExpression(left, index)
+	case index.Type() == object.RANGE_OBJ && (left.Type() == object.ARRAY_OBJ || left.Type() == object.STRING_OBJ):
+		return sliceByRange(left, index.(*object.Range))
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.STRUCT_OBJ && index.Type() == object.STRING_OBJ:
+		return structField(left.(*object.Struct), index.(*object.String).Value)
+	case left.Type() == object.VARIANT_OBJ && index.Type() == object.STRING_OBJ:
+		return variantField(left.(*object.Variant), index.(*object.String).Value)
 	default:
+		if result, ok := callOperator(left, "__index__", index); ok {
+			return result
+		}
 		return newError("index operator not supported: %s", left.Type())
 	}
 }
 
+// normalizeIndex resolves a possibly negative index against length.
+// It reports false when the index is out of range.
+func normalizeIndex(idx int64, length int) (int64, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+
+	return idx, true
+}
+
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	maximum := int64(len(arrayObject.Elements) - 1)
 
-	if idx < 0 || idx > maximum {
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
+	if !ok {
 		return NULL
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+func evalTupleIndexExpression(tuple, index object.Object) object.Object {
+	tupleObject := tuple.(*object.Tuple)
+
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(tupleObject.Elements))
+	if !ok {
+		return NULL
+	}
+
+	return tupleObject.Elements[idx]
+}
+
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	stringObject := str.(*object.String)
+
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(stringObject.Value))
+	if !ok {
+		return NULL
+	}
+
+	return &object.String{Value: stringObject.Value[idx : idx+1]}
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) (object.Object, bool) {
+	left, skipped := evalChainLeft(node.Left, node.Optional, env)
+	if skipped || isError(left) {
+		return left, skipped
+	}
+
+	var bounds [3]*int64
+	for i, boundNode := range []ast.Expression{node.Start, node.End, node.Step} {
+		if boundNode == nil {
+			continue
+		}
+
+		bound := Eval(boundNode, env)
+		if isError(bound) {
+			return bound, false
+		}
+
+		switch bound := bound.(type) {
+		case *object.Integer:
+			bounds[i] = &bound.Value
+		case *object.Null:
+		default:
+			return newError("slice bound must be INTEGER, got %s", bound.Type()), false
+		}
+	}
+
+	return sliceObject(left, bounds[0], bounds[1], bounds[2]), false
+}
+
+// sliceObject returns the part of an array or string selected by [start:end:step]
+func sliceObject(left object.Object, start, end, step *int64) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		indices, err := sliceIndices(len(left.Elements), start, end, step)
+		if err != nil {
+			return err
+		}
+
+		elements := make([]object.Object, len(indices))
+		for i, idx := range indices {
+			elements[i] = left.Elements[idx]
+		}
+
+		return &object.Array{Elements: elements}
+	case *object.String:
+		indices, err := sliceIndices(len(left.Value), start, end, step)
+		if err != nil {
+			return err
+		}
+
+		out := make([]byte, len(indices))
+		for i, idx := range indices {
+			out[i] = left.Value[idx]
+		}
+
+		return &object.String{Value: string(out)}
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+}
+
+// sliceIndices returns the indices selected by [start:end:step] on a sequence of
+// the given length, following Python's rules for omitted and negative bounds.
+func sliceIndices(length int, start, end, step *int64) ([]int, *object.Error) {
+	n := int64(length)
+
+	stride := int64(1)
+	if step != nil {
+		stride = *step
+	}
+
+	if stride == 0 {
+		return nil, newError("slice step cannot be zero")
+	}
+
+	// resolve clamps a bound into [lower, upper] after applying negative indexing
+	resolve := func(bound *int64, fallback, lower, upper int64) int64 {
+		if bound == nil {
+			return fallback
+		}
+
+		idx := *bound
+		if idx < 0 {
+			idx += n
+		}
+
+		if idx < lower {
+			return lower
+		}
+		if idx > upper {
+			return upper
+		}
+		return idx
+	}
+
+	var from, to int64
+	if stride > 0 {
+		from = resolve(start, 0, 0, n)
+		to = resolve(end, n, 0, n)
+	} else {
+		from = resolve(start, n-1, -1, n-1)
+		to = resolve(end, -1, -1, n-1)
+	}
+
+	var indices []int
+	for i := from; (stride > 0 && i < to) || (stride < 0 && i > to); i += stride {
+		indices = append(indices, int(i))
+
+		// Stop once the next index would pass to, before i += stride can overflow
+		if (stride > 0 && to-i <= stride) || (stride < 0 && to-i >= stride) {
+			break
+		}
+	}
+
+	return indices, nil
+}
+
+func evalPropertyExpression(node *ast.PropertyExpression, env *object.Environment) (object.Object, bool) {
+	left, skipped := evalChainLeft(node.Left, node.Optional, env)
+	if skipped || isError(left) {
+		return left, skipped
+	}
+
+	return propertyOf(left, node.Property.Value), false
+}
+
+// propertyOf returns the property name of obj
+func propertyOf(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.Hash:
+		value, ok := obj.Get(&object.String{Value: name})
+		if !ok {
+			return NULL
+		}
+		return value
+	case *object.Struct:
+		return structField(obj, name)
+	case *object.Variant:
+		return variantField(obj, name)
+	case *object.EnumType:
+		return enumVariant(obj, name)
+	case *object.Instance:
+		member, ok := instanceMember(obj, name)
+		if !ok {
+			return newError("undefined property %s for %s", name, obj.Class.Name)
+		}
+		return member
+	case *object.Super:
+		method, ok := superMethod(obj, name)
+		if !ok {
+			return newError("undefined method %s for %s", name, obj.Inspect())
+		}
+		return method
+	default:
+		return newError("property access not supported: %s", obj.Type())
+	}
+}
+
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pairNode := range node.Pairs {
+		key := Eval(pairNode.Key, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pairNode.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
-
+		hash.Set(hashKey, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
+		if result, ok := callOperator(hash, "__index__", index); ok {
+			return result
+		}
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := hashObject.Get(key)
 	if !ok {
+		if result, ok := callOperator(hash, "__index__", index); ok {
+			return result
+		}
 		return NULL
 	}
 
-	return pair.Value
+	return value
 }
@@ -4,7 +4,10 @@ import (
 	"gtihub.com/yudai2929/monkey-lang/lexer"
 	"gtihub.com/yudai2929/monkey-lang/object"
 	"gtihub.com/yudai2929/monkey-lang/parser"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -205,6 +208,17 @@ func TestErrorHandling(t *testing.T) {
 		{"foobar", "identifier not found: foobar"},
 		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
 		{`{"name": "Monkey"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1, fn(x) { x }]: 1}`, "unusable as hash key: ARRAY"},
+		{`5?.foo`, "property access not supported: INTEGER"},
+		{`let h = {"a": null}; h?.a.b`, "property access not supported: NULL"},
+		{`let h = {"a": null}; h?.a[0]`, "index operator not supported: NULL"},
+		{`null["a"]`, "index operator not supported: NULL"},
+		{`fn(a, b) { a }(1)`, "wrong number of arguments. got=1, want=2"},
+		{`fn(a) { a }(1, 2)`, "wrong number of arguments. got=2, want=1"},
+		{`[1].map(|a, b| a)`, "wrong number of arguments. got=1, want=2"},
+		{`reduce([1], 0, |acc| acc)`, "wrong number of arguments. got=2, want=1"},
+		{`1 |> fn() { 2 }`, "wrong number of arguments. got=1, want=0"},
+		{`{"__add__": fn(a) { a }} + 1`, "wrong number of arguments. got=2, want=1"},
 	}
 
 	for _, tt := range tests {
@@ -385,7 +399,9 @@ func TestArrayIndexExpressions(t *testing.T) {
 		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
 		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
 		{"[1, 2, 3][3]", nil},
-		{"[1, 2, 3][-1]", nil},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[1, 2, 3][-4]", nil},
 	}
 
 	for _, tt := range tests {
@@ -417,26 +433,45 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for i, pair := range result.Pairs() {
+		if pair.Key.Inspect() != expected[i].key.Inspect() {
+			t.Errorf("pair %d has wrong key. want=%s, got=%s", i, expected[i].key.Inspect(), pair.Key.Inspect())
+		}
+
+		value, ok := result.Get(expected[i].key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
+			continue
 		}
 
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, value, expected[i].value)
+	}
+}
+
+func TestHashInspectIsInsertionOrdered(t *testing.T) {
+	input := `let h = {"zeta": 1, "alpha": 2, 3: 3, "mid": 4}; h`
+
+	for i := 0; i < 20; i++ {
+		evaluated := testEval(input)
+		if evaluated.Inspect() != "{[zeta: 1 alpha: 2 3: 3 mid: 4]}" {
+			t.Fatalf("hash inspected out of order. got=%s", evaluated.Inspect())
+		}
 	}
 }
 
@@ -452,6 +487,370 @@ func TestHashIndexExpressions(t *testing.T) {
 		{`{5: 5}[5]`, 5},
 		{`{true: 5}[true]`, 5},
 		{`{false: 5}[false]`, 5},
+		{`{[1, "a"]: 5}[[1, "a"]]`, 5},
+		{`{[1, [2, 3]]: 5}[[1, [2, 3]]]`, 5},
+		{`{[1, 2]: 5}[[2, 1]]`, nil},
+		{`{[1, 2]: 5, [1, 2]: 6}[[1, 2]]`, 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`split("a,b,c", ",")`, []string{"a", "b", "c"}},
+		{`split("abc", "")`, []string{"a", "b", "c"}},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join([], "-")`, ""},
+		{`trim("  hi  ")`, "hi"},
+		{`contains("monkey", "key")`, true},
+		{`contains("monkey", "donkey")`, false},
+		{`starts_with("monkey", "mon")`, true},
+		{`ends_with("monkey", "mon")`, false},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`upper("Monkey")`, "MONKEY"},
+		{`lower("Monkey")`, "monkey"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`"" * 9223372036854775807`, ""},
+		{`index_of("monkey", "key")`, 3},
+		{`index_of("monkey", "x")`, -1},
+		{`substring("monkey", 1, 4)`, "onk"},
+		{`format("%s is %d years old", "Monkey", 5)`, "Monkey is 5 years old"},
+		{`format("100%% %s", [1, 2])`, "100% [[1 2]]"},
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"b" > "a"`, true},
+		{`"ab" * 2`, "abab"},
+		{`3 * "-"`, "---"},
+		{`"ab" * 0`, ""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case []string:
+			arr, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if len(arr.Elements) != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), len(arr.Elements))
+				continue
+			}
+
+			for i, el := range expected {
+				testStringObject(t, arr.Elements[i], el)
+			}
+		}
+	}
+}
+
+func TestStringBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`split("a")`, "wrong number of arguments. got=1, want=2"},
+		{`upper(1)`, "argument to `upper` must be STRING, got INTEGER"},
+		{`join([1], ",")`, "elements of `join` must be STRING, got INTEGER"},
+		{`repeat("a", -1)`, "negative repeat count: -1"},
+		{`"a" * -1`, "negative repeat count: -1"},
+		{`"ab" * 9223372036854775807`, "repeat count too large: 9223372036854775807"},
+		{`repeat("ab", 536870913)`, "repeat count too large: 536870913"},
+		{`substring("abc", 2, 5)`, "substring out of range: [2:5] with length 3"},
+		{`format("%d", "a")`, "format: %d expects INTEGER, got STRING"},
+		{`format("%s %s", "a")`, "format: missing argument for %s"},
+		{`format("%s", "a", "b")`, "format: too many arguments. got=2, used=1"},
+		{`"a" / "b"`, "unknown operator: STRING / STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func testStringObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%q, want=%q", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func TestTemplateLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Monkey"; let age = 4; "Hello ${name}, you are ${age + 1}"`, "Hello Monkey, you are 5"},
+		{`"${1}${2}"`, "12"},
+		{`"list: ${[1, 2]}"`, "list: [[1 2]]"},
+		{`"cost: $5"`, "cost: $5"},
+		{`let x = "in"; "out ${"mid ${x}"}"`, "out mid in"},
+		{`"${ {"a": 1}["a"] }"`, "1"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc"[0]`, "a"},
+		{`"abc"[2]`, "c"},
+		{`"abc"[-1]`, "c"},
+		{`"abc"[3]`, nil},
+		{`"abc"[-4]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", "[[2 3]]"},
+		{"[1, 2, 3, 4, 5][:2]", "[[1 2]]"},
+		{"[1, 2, 3, 4, 5][3:]", "[[4 5]]"},
+		{"[1, 2, 3, 4, 5][:]", "[[1 2 3 4 5]]"},
+		{"[1, 2, 3, 4, 5][::-1]", "[[5 4 3 2 1]]"},
+		{"[1, 2, 3, 4, 5][::2]", "[[1 3 5]]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[[4 5]]"},
+		{"[1, 2, 3, 4, 5][:-2]", "[[1 2 3]]"},
+		{"[1, 2, 3, 4, 5][3:1:-1]", "[[4 3]]"},
+		{"[1, 2, 3, 4, 5][10:]", "[[]]"},
+		{"[1, 2, 3, 4, 5][-10:2]", "[[1 2]]"},
+		{`"monkey"[1:3]`, "on"},
+		{`"monkey"[::-1]`, "yeknom"},
+		{`"monkey"[-3:]`, "key"},
+		{`let n = 2; "monkey"[n:n + 2]`, "nk"},
+		{"[1, 2, 3][1:2:9223372036854775807]", "[[2]]"},
+		{"[1, 2, 3][::9223372036854775807]", "[[1]]"},
+		{"[1, 2, 3][::-9223372036854775807]", "[[3]]"},
+		{"[1, 2, 3][::-9223372036854775807 - 1]", "[[3]]"},
+		{`"monkey"[1::9223372036854775807]`, "o"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong slice for %s. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSliceErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1, 2][::0]", "slice step cannot be zero"},
+		{`[1, 2]["a":]`, "slice bound must be INTEGER, got STRING"},
+		{`{"a": 1}[1:2]`, "slice operator not supported: HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1, 2, 3]", false},
+		{`[1, "a"] == [1, "b"]`, false},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} != {"a": 1, "b": 2}`, true},
+		{`{} == {}`, true},
+		{"if (false) { 1 } == if (false) { 2 }", true},
+		{"[1] == 1", false},
+		{"let f = fn(x) { x }; f == f", true},
+		{"fn(x) { x } == fn(x) { x }", false},
+		{"len == len", true},
+		{"len == first", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestContainsBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`contains("monkey", "key")`, true},
+		{`contains([1, [2, 3]], [2, 3])`, true},
+		{`contains([1, 2], 3)`, false},
+		{`contains([{"a": 1}], {"a": 1})`, true},
+		{`contains({"a": 1}, "a")`, true},
+		{`contains({[1, 2]: 1}, [1, 2])`, true},
+		{`contains({"a": 1}, "b")`, false},
+		{`contains(1, 1)`, "argument to `contains` not supported, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestNullLiteralAndNullSafeOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null", nil},
+		{"null == null", true},
+		{"null != null", false},
+		{"null == 0", false},
+		{"!null", true},
+		{"let x = if (false) { 1 }; x == null", true},
+		{"null ?? 5", 5},
+		{"3 ?? 5", 3},
+		{"false ?? 5", false},
+		{"null ?? null ?? 7", 7},
+		{"1 ?? undefinedIdentifier", 1},
+		{`let cfg = {"server": {"port": 80}}; cfg?.server?.port`, 80},
+		{`let cfg = {"server": null}; cfg?.server?.port`, nil},
+		{`let cfg = {}; cfg?.server?.port ?? 8080`, 8080},
+		{`let cfg = null; cfg?["server"]`, nil},
+		{`let cfg = null; cfg?[undefinedIdentifier]`, nil},
+		{`let xs = [1, 2]; xs?[1]`, 2},
+		{`let xs = null; xs?[0] ?? 9`, 9},
+		{`let xs = null; xs?[1:]`, nil},
+		{`let h = null; h?.a.b`, nil},
+		{`let h = null; h?.a[0]`, nil},
+		{`let h = null; h?.a[0:1].b`, nil},
+		{`let h = null; h?.f()`, nil},
+		{`let h = null; h?.a.f()`, nil},
+		{`let h = null; h?.a.f()()[0]`, nil},
+		{`let h = null; h?[0].a(undefinedIdentifier)`, nil},
+		{`let h = null; h?.a.b ?? 3`, 3},
+		{`let h = {"a": {"b": [4]}}; h?.a.b[0]`, 4},
+		{`let h = {"f": fn() { {"x": 5} }}; h?.f().x`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestDotAccessAndMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let cfg = {"server": {"port": 8080}}; cfg.server.port`, 8080},
+		{`let cfg = {"server": {}}; cfg.server.port`, nil},
+		{`let cfg = {"server": null}; cfg.server?.port ?? 1`, 1},
+		{`"monkey".upper()`, "MONKEY"},
+		{`let s = " hi "; s.trim().len()`, 2},
+		{`"a,b".split(",").join("-")`, "a-b"},
+		{`[1, 2, 3].map(fn(x) { x * 2 })`, "[[2 4 6]]"},
+		{`[1, 2, 3, 4].filter(fn(x) { x > 2 }).len()`, 2},
+		{`[1, 2].push(3).last()`, 3},
+		{`{"a": 1, "b": 2}.keys()`, `[[a b]]`},
+		{`{"a": 1, "b": 2}.values()`, `[[1 2]]`},
+		{`{"a": 1}.len()`, 1},
+		{`let obj = {"double": fn(x) { x * 2 }}; obj.double(21)`, 42},
+		{`let obj = {"len": fn() { 99 }}; obj.len()`, 99},
+		{`let s = null; s?.upper()`, nil},
 	}
 
 	for _, tt := range tests {
@@ -460,8 +859,1218 @@ func TestHashIndexExpressions(t *testing.T) {
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %s. want=%s, got=%s", tt.input, expected, evaluated.Inspect())
+			}
 		default:
 			testNullObject(t, evaluated)
 		}
 	}
 }
+
+func TestMethodCallErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`"monkey".push(1)`, "undefined method push for STRING"},
+		{`5.len()`, "undefined method len for INTEGER"},
+		{`let x = 5; x.foo`, "property access not supported: INTEGER"},
+		{`[1].map(fn(x) { x + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`"a".upper(1)`, "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestPipelineExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`[1, 2, 3, 4] |> map(fn(x) { x * 10 }) |> filter(fn(x) { x > 10 }) |> sum()`, 90},
+		{`[1, 2, 3] |> sum`, 6},
+		{`[1, 2, 3] |> reduce(0, fn(acc, x) { acc + x })`, 6},
+		{`let add = fn(a, b) { a + b }; 1 |> add(2)`, 3},
+		{`let inc = fn(x) { x + 1 }; 1 + 1 |> inc()`, 3},
+		{`"a,b" |> split(",") |> len()`, 2},
+		{`let m = {"twice": fn(x) { x * 2 }}; 21 |> m.twice()`, 42},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`1 |> 2`, "not a function: INTEGER"},
+		{`[1] |> map(fn(x) { x + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`[1, "a"] |> sum()`, "elements of `sum` must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let double = |x| x * 2; double(5);", 10},
+		{"let add = |a, b| a + b; add(2, 3);", 5},
+		{"let answer = || 42; answer();", 42},
+		{"let adder = |x| |y| x + y; adder(2)(3);", 5},
+		{"[1, 2, 3] |> map(|x| x * x) |> sum()", 14},
+		{"[1, 2, 3, 4].filter(|x| x > 2).len()", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let f = fn() { let a = 6; a }; f() + a;", 11},
+		{"const a = 5; let f = fn(a) { a }; f(1);", 1},
+		{"let a = 5; let a = 6; a;", 6},
+		{"const a = 5; let a = 6;", "cannot reassign constant a"},
+		{"const a = 5; const a = 6;", "cannot reassign constant a"},
+		{"let a = 5; const a = 6; let a = 7;", "cannot reassign constant a"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestLockedGlobalEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+
+	prelude := parser.New(lexer.New(`let config = {"port": 80}; let helper = fn(x) { x + 1 };`)).ParseProgram()
+	Eval(prelude, env)
+	env.Lock()
+
+	script := parser.New(lexer.New(`let helper = fn(x) { x };`)).ParseProgram()
+	evaluated := Eval(script, env)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "cannot reassign constant helper" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+
+	script = parser.New(lexer.New(`let port = config.port; helper(port)`)).ParseProgram()
+	testIntegerObject(t, Eval(script, env), 81)
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`struct Point { x, y }; let p = Point(1, 2); p.x + p.y`, 3},
+		{`struct Point { x, y }; Point(1, 2)["y"]`, 2},
+		{`struct Point { x, y }; Point(1, 2) == Point(1, 2)`, true},
+		{`struct Point { x, y }; Point(1, 2) == Point(2, 1)`, false},
+		{`struct A { x }; struct B { x }; A(1) == B(1)`, false},
+		{`struct Point { x, y }; let h = {Point(1, 2): "a"}; h[Point(1, 2)]`, "a"},
+		{`struct Point { x, y }; "${Point(1, 2)}"`, "Point{x: 1, y: 2}"},
+		{`struct Point { x, y }; "${Point}"`, "struct Point { x, y }"},
+		{`struct Counter { n, inc }; let c = Counter(1, fn(x) { x + 1 }); c.inc(c.n)`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`struct Point { x, y }; Point(1)`, "wrong number of fields for Point. got=1, want=2"},
+		{`struct Point { x, y }; Point(1, 2).z`, "unknown field z for Point"},
+		{`struct Point { x, y }; Point(1, 2)["z"]`, "unknown field z for Point"},
+		{`const Point = 1; struct Point { x }`, "cannot reassign constant Point"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	prelude := `enum Result { Ok(value), Err(msg), Empty }; `
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Ok(1).value`, 1},
+		{`Result.Ok(2).value`, 2},
+		{`Err("x")["msg"]`, "x"},
+		{`Ok(1) == Result.Ok(1)`, true},
+		{`Ok(1) == Ok(2)`, false},
+		{`Ok(1) == Err(1)`, false},
+		{`Empty == Result.Empty`, true},
+		{`tag(Err("x"))`, "Err"},
+		{`Empty.tag()`, "Empty"},
+		{`type(Ok(1))`, "Result"},
+		{`struct Point { x, y }; type(Point(1, 2))`, "Point"},
+		{`type(1)`, "INTEGER"},
+		{`let h = {Ok(1): "one", Empty: "none"}; h[Ok(1)] + h[Empty]`, "onenone"},
+		{`"${Ok(Err(1))} ${Empty}"`, "Ok(Err(1)) Empty"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	prelude := `enum Result { Ok(value), Err(msg), Empty };
+struct Point { x, y };
+let describe = fn(r) { match r { Ok(v) => "ok ${v}", Err(m) => "err ${m}", Empty => "empty" } };
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`describe(Ok(1))`, "ok 1"},
+		{`describe(Result.Err("boom"))`, "err boom"},
+		{`describe(Empty)`, "empty"},
+		{`describe(1)`, nil},
+		{`match Ok(Ok(2)) { Ok(Err(_)) => 1, Ok(Ok(n)) => n }`, 2},
+		{`match Ok(3) { Result.Ok(3) => "three", _ => "other" }`, "three"},
+		{`match Ok(4) { Ok(3) => "three", _ => "other" }`, "other"},
+		{`match Point(1, 2) { Point(0, y) => y, Point(x, y) => x + y }`, 3},
+		{`match -1 { 1 => "one", -1 => "minus one" }`, "minus one"},
+		{`match "b" { "a" => 1, "b" => 2 }`, 2},
+		{`match [1, 2] { [1, 2] => true, _ => false }`, true},
+		{`match 5 { n => n * 2 }`, 10},
+		{`let v = 1; match Ok(2) { Ok(v) => v }; v`, 1},
+		{`let opt = Ok(Ok(5)); match (opt) { Ok(opt) => describe(opt) }`, "ok 5"},
+		{`let e = Empty; match Ok(6) { Ok(e) => e }`, 6},
+		{`let e = Empty; match Empty { Ok(_) => 0, e => 1 }`, 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestEnumAndMatchErrors(t *testing.T) {
+	prelude := `enum Result { Ok(value), Err(msg), Empty }; `
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`Ok(1, 2)`, "wrong number of fields for Result.Ok(value). got=2, want=1"},
+		{`Result.Missing`, "unknown variant Missing for enum Result"},
+		{`Ok(1).msg`, "unknown field msg for Result.Ok(value)"},
+		{`tag(1)`, "argument to `tag` must be VARIANT, got INTEGER"},
+		{`match Ok(1) { Ok(a, b) => a }`, "wrong number of fields in pattern Ok(a, b). got=2, want=1"},
+		{`match 1 { len(x) => x }`, "invalid pattern: len(x)"},
+		{`match 1 { missing => 1 }; match 1 { missing.x => 1 }`, "identifier not found: missing"},
+		{`const Ok = 1; enum Result { Ok(value) }`, "cannot reassign constant Ok"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	prelude := `class Animal {
+	init(name) { self.name = name; self.sound = "..." }
+	speak() { "${self.name} says ${self.sound}" }
+	rename(name) { self.name = name; self }
+}
+class Dog extends Animal {
+	init(name) { super.init(name); self.sound = "woof" }
+	speak() { super.speak() + "!" }
+}
+class Empty {}
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Animal("Cat").speak()`, "Cat says ..."},
+		{`Dog("Rex").speak()`, "Rex says woof!"},
+		{`Dog("Rex").rename("Max").speak()`, "Max says woof!"},
+		{`let d = Dog("Rex"); d.sound = "grr"; d.speak()`, "Rex says grr!"},
+		{`let d = Dog("Rex"); let speak = d.speak; d.name = "Max"; speak()`, "Max says woof!"},
+		{`let d = Dog("Rex"); d.name`, "Rex"},
+		{`"${Dog("Rex")}"`, "Dog{name: Rex, sound: woof}"},
+		{`"${Empty()}"`, "Empty{}"},
+		{`type(Dog("Rex"))`, "Dog"},
+		{`instance_of(Dog("Rex"), Animal)`, true},
+		{`instance_of(Animal("Cat"), Dog)`, false},
+		{`instance_of(1, Dog)`, false},
+		{`let d = Dog("Rex"); d == d`, true},
+		{`Dog("Rex") == Dog("Rex")`, false},
+		{`class Counter { init() { self.n = 0 } inc() { self.n = self.n + 1 } }; let c = Counter(); c.inc(); c.inc(); c.n`, 2},
+		{`class Adder { init(n) { self.n = n } add(x) { x + self.n } }; [1, 2] |> map(Adder(10).add) |> sum()`, 23},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestClassErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let A = 1; class B extends A {}`, "superclass of B must be CLASS, got INTEGER"},
+		{`class A {}; A(1)`, "wrong number of arguments. got=1, want=0"},
+		{`class A { init(x) { 1 } }; A()`, "wrong number of arguments to A.init. got=0, want=1"},
+		{`class A {}; A().x`, "undefined property x for A"},
+		{`class A {}; A().f()`, "undefined method f for INSTANCE"},
+		{`class A {}; class B extends A { f() { super.f() } }; B().f()`, "undefined method f for SUPER"},
+		{`let h = {}; h.x = 1`, "cannot assign property x of HASH"},
+		{`class A { init() { self.x = y } }; A()`, "identifier not found: y"},
+		{`instance_of(1, 2)`, "argument to `instance_of` must be CLASS, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	prelude := `let vec = fn(x, y) {
+	{
+		"x": x, "y": y,
+		"__add__": fn(a, b) { vec(a.x + b.x, a.y + b.y) },
+		"__mul__": fn(a, k) { vec(a.x * k, a.y * k) },
+		"__neg__": fn(a) { vec(-a.x, -a.y) },
+		"__eq__": fn(a, b) { a.x == b.x },
+		"__lt__": fn(a, b) { a.x < b.x },
+		"__index__": fn(a, i) { if (i == 0) { a.x } else { a.y } },
+		"__str__": fn(a) { "<${a.x}, ${a.y}>" }
+	}
+};
+class Money {
+	init(cents) { self.cents = cents }
+	__add__(other) { Money(self.cents + other.cents) }
+	__sub__(other) { Money(self.cents - other.cents) }
+	__gt__(other) { self.cents > other.cents }
+	__str__() { "$${self.cents / 100}" }
+}
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(vec(1, 2) + vec(3, 4)).y`, 6},
+		{`(vec(1, 2) * 3).x`, 3},
+		{`(-vec(1, 2)).y`, -2},
+		{`vec(1, 2)[1]`, 2},
+		{`vec(1, 2)["x"]`, 1},
+		{`let h = {"a": 1, "__index__": fn(self, k) { self["a"] + len(k) }}; h["a"] + h["bcd"]`, 5},
+		{`let h = {"__index__": fn(self, k) { k[0] }}; h[[7]]`, 7},
+		{`class Grid { init() { self.cells = {"a": 1} } __index__(k) { self.cells[k] } }; Grid()["a"]`, 1},
+		{`vec(1, 2) == vec(1, 5)`, true},
+		{`vec(1, 2) != vec(1, 5)`, false},
+		{`vec(1, 2) != vec(2, 2)`, true},
+		{`vec(1, 2) < vec(2, 0)`, true},
+		{`vec(2, 0) > vec(1, 2)`, true},
+		{`"${vec(1, 2)}"`, "<1, 2>"},
+		{`str(vec(1, 2) + vec(1, 1))`, "<2, 3>"},
+		{`format("v=%s", vec(0, 0))`, "v=<0, 0>"},
+		{`(Money(150) + Money(250)).cents`, 400},
+		{`(Money(500) - Money(150)).cents`, 350},
+		{`Money(500) > Money(150)`, true},
+		{`Money(100) < Money(150)`, true},
+		{`str(Money(300))`, "$3"},
+		{`str(1)`, "1"},
+		{`{"a": 1} == {"a": 1}`, true},
+		{`{"a": 1} + {"b": 2}`, "unknown operator: HASH + HASH"},
+		{`Money(1) * 2`, "type mismatch: INSTANCE * INTEGER"},
+		{`str({"__str__": fn(s) { 1 }})`, "__str__ must return STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	prelude := `let naturals = fn(n) { yield n; yield* naturals(n + 1) };
+let three = fn() { yield 1; yield 2; yield 3; };
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let g = three(); next(g); next(g)`, 2},
+		{`let g = three(); next(g); next(g); next(g); next(g)`, nil},
+		{`let g = three(); g.next() + g.next()`, 3},
+		{`len(three())`, 3},
+		{`first(naturals(5))`, 5},
+		{`first(rest(three()))`, 2},
+		{`sum(three())`, 6},
+		{`reduce(three(), 10, |acc, x| acc - x)`, 4},
+		{`sum(take(naturals(1), 100))`, 5050},
+		{`naturals(1) |> map(|x| x * x) |> filter(|x| x > 10) |> first()`, 16},
+		{`len(take(naturals(0), 3))`, 3},
+		{`len(take(three(), 10))`, 3},
+		{`to_array(three()) == [1, 2, 3]`, true},
+		{`to_array(fn() { yield* [1, 2]; yield* three() }()) == [1, 2, 1, 2, 3]`, true},
+		{`let g = fn() { let x = yield 1; return 5; yield 2 }(); to_array(g) == [1]`, true},
+		{`let g = fn(x) { match x { 0 => yield 1, _ => yield 2 } }(5); next(g)`, 2},
+		{`class Range { init(n) { self.n = n } each() { yield* take(naturals(0), self.n) } }; Range(4).each().sum()`, 6},
+		{`let g = three(); let f = fn() { next(g) }; f(); f()`, 2},
+		{`three()`, "generator"},
+		{`let g = three(); let r = rest(g); [r == g, next(g)] == [true, 2]`, true},
+		{`let g = naturals(0); next(g); close(g); next(g)`, nil},
+		{`let g = naturals(0); g.close(); to_array(g) == []`, true},
+		{`let g = naturals(0) |> map(|x| x * 2); next(g); close(g); next(g)`, nil},
+		{`let g = three(); close(g); close(g); len(g)`, 0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong Inspect. expected=%q, got=%q", expected, evaluated.Inspect())
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestDroppedGeneratorsReleaseGoroutines(t *testing.T) {
+	input := `let naturals = fn(n) { yield n; yield* naturals(n + 1) }; sum(take(naturals(0), 5))`
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		testIntegerObject(t, testEval(input), 10)
+	}
+
+	// Each dropped generator is closed by a finalizer, which needs a collection to run and
+	// lets the generators it was delegating to be collected in turn.
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("generator goroutines leaked. before=%d, after=%d", before, after)
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let g = fn() { yield 1; missing }(); next(g); next(g)`, "identifier not found: missing"},
+		{`to_array(fn() { yield 1; missing }())`, "identifier not found: missing"},
+		{`fn() { yield* 1 }() |> next()`, "yield* not supported: INTEGER"},
+		{`fn(x) { yield x }()`, "wrong number of arguments. got=0, want=1"},
+		{`next([1])`, "argument to `next` must be GENERATOR, got ARRAY"},
+		{`take(1, 2)`, "argument to `take` must be ARRAY or GENERATOR, got INTEGER"},
+		{`sum(1)`, "argument to `sum` must be ARRAY, got INTEGER"},
+		{`fn() { yield 1 }() |> map(|x| x + missing) |> next()`, "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`wait(spawn(fn(x, y) { x + y }, 1, 2))`, 3},
+		{`let t = spawn(|| 5); t.wait() + t.wait()`, 10},
+		{`let ch = channel(); spawn(fn() { send(ch, 7) }); receive(ch)`, 7},
+		{`let ch = channel(2); ch.send(1); ch.send(2); ch.close(); [ch.receive(), ch.receive(), ch.receive()] == [1, 2, null]`, true},
+		{`let a = channel(); let b = channel(1); send(b, "x"); select([a, b]) == [1, "x"]`, true},
+		{`let a = channel(); close(a); select([a]) == [0, null]`, true},
+		{`let ch = channel();
+let wg = waitgroup();
+wg.add(4);
+[1, 2, 3, 4] |> map(|i| spawn(fn() { send(ch, i); wg.done() }));
+spawn(fn() { wg.wait(); close(ch) });
+let drain = fn(acc) { let v = receive(ch); if (v == null) { acc } else { drain(acc + v) } };
+drain(0)`, 10},
+		{`let results = [1, 2, 3, 4, 5] |> map(|i| spawn(|| i * i)) |> map(wait); sum(results)`, 55},
+		{`class Box { init() { self.v = 0 } }; let b = Box(); [1, 2, 3] |> map(|i| spawn(fn() { b.v = i })) |> map(wait); b.v > 0`, true},
+		{`let wg = waitgroup(); wg.wait()`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestConcurrencyErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`wait(spawn(fn() { missing }))`, "identifier not found: missing"},
+		{`wait(spawn(1))`, "not a function: INTEGER"},
+		{`let ch = channel(); close(ch); send(ch, 1)`, "send on closed channel"},
+		{`let ch = channel(); close(ch); close(ch)`, "close of closed channel"},
+		{`channel(-1)`, "negative channel capacity: -1"},
+		{`select([])`, "select needs at least one channel"},
+		{`select([1])`, "elements of `select` must be CHANNEL, got INTEGER"},
+		{`waitgroup().done()`, "negative waitgroup counter"},
+		{`wait(1)`, "argument to `wait` must be TASK or WAITGROUP, got INTEGER"},
+		{`spawn()`, "wrong number of arguments. got=0, want at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestAsyncAwait(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let f = async fn(x) { x * 2 }; await f(21)`, 42},
+		{`let f = async fn(x) { return x; 0 }; await f(1)`, 1},
+		{`let f = async |x| x + 1; let g = async fn(x) { await f(x) + await f(x) }; await g(1)`, 4},
+		{`await 5`, 5},
+		{`let f = async fn() { await sleep(1); 7 }; await f()`, 7},
+		{`let log = channel(4);
+let first = async fn() { send(log, "a1"); await sleep(1); send(log, "a2") };
+let a = first();
+let second = async fn() { send(log, "b1"); await a; send(log, "b2") };
+await second();
+[receive(log), receive(log), receive(log), receive(log)] == ["a1", "b1", "a2", "b2"]`, true},
+		{`let log = channel(2);
+let f = async fn() { send(log, "body") };
+let r = f();
+send(log, "caller");
+await r;
+[receive(log), receive(log)] == ["caller", "body"]`, true},
+		{`let f = async fn(x) { await sleep(x); x }; await all([f(5), f(1), 3]) == [5, 1, 3]`, true},
+		{`len(await all([]))`, 0},
+		{`let f = async fn() { 1 }; str(f())`, "future(pending)"},
+		{`let f = async fn() { 1 }; let r = f(); await r; r`, "future(1)"},
+		{`let f = async fn() { |x| await x }; let g = await f(); g(async fn() { 3 }())`, 3},
+		{`let f = async fn(x) { await sleep(1); x }; wait(spawn(fn() { await f(9) }))`, 9},
+		{`let work = fn(ms) { await sleep(ms); ms };
+let a = spawn(work, 5);
+let b = spawn(work, 30);
+[wait(a), wait(b)] == [5, 30]`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong Inspect. expected=%q, got=%q", expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestAsyncAwaitErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let f = async fn() { missing }; await f()`, "identifier not found: missing"},
+		{`let f = async fn() { await sleep(1); missing }; let g = async fn() { await f(); 1 }; await g()`, "identifier not found: missing"},
+		{`let f = async fn(x) { x }; f()`, "wrong number of arguments. got=0, want=1"},
+		{`let f = async fn() { missing }; await all([f(), 1])`, "identifier not found: missing"},
+		{`sleep("1")`, "argument to `sleep` must be INTEGER, got STRING"},
+		{`all(1)`, "argument to `all` must be ARRAY, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestConcurrentAwaiters(t *testing.T) {
+	input := `let work = async fn(ms) { await sleep(ms); ms }; await work(20) + await work(1)`
+
+	var wg sync.WaitGroup
+	results := make([]object.Object, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = testEval(input)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		testIntegerObject(t, result, 21)
+	}
+}
+
+func TestEvalRunsUnawaitedAsyncCalls(t *testing.T) {
+	env := object.NewEnvironment()
+
+	program := parser.New(lexer.New(`let log = channel(1); let f = async fn() { await sleep(1); send(log, "done") }; f(); 0`)).ParseProgram()
+	testIntegerObject(t, Eval(program, env), 0)
+
+	log, _ := env.Get("log")
+	select {
+	case value := <-log.(*object.Channel).Chan():
+		testStringObject(t, value, "done")
+	default:
+		t.Errorf("async call did not run before Eval returned")
+	}
+}
+
+func TestHostFutures(t *testing.T) {
+	env := object.NewEnvironment()
+
+	release := make(chan string)
+	env.Set("fetch", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		future := object.NewFuture()
+		go func() {
+			future.Resolve(&object.String{Value: <-release + args[0].Inspect()})
+		}()
+		return future
+	}})
+	env.Set("fail", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		future := object.NewFuture()
+		go future.Reject("connection refused")
+		return future
+	}})
+
+	go func() { release <- "hello " }()
+
+	program := parser.New(lexer.New(`let get = async fn(name) { await fetch(name) }; await get("world")`)).ParseProgram()
+	testStringObject(t, Eval(program, env), "hello world")
+
+	program = parser.New(lexer.New(`await fail()`)).ParseProgram()
+	evaluated := Eval(program, env)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "connection refused" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestSetsAndTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{1, 2, 2, 3}`, "{1, 2, 3}"},
+		{`len({1, 2, 2, 3})`, 3},
+		{`set()`, "set()"},
+		{`set([3, 1, 3])`, "{3, 1}"},
+		{`{1, 2} == {2, 1}`, true},
+		{`{1, 2} == {1, 3}`, false},
+		{`union({1, 2}, {2, 3})`, "{1, 2, 3}"},
+		{`intersection({1, 2, 3}, {3, 2, 4})`, "{2, 3}"},
+		{`difference({1, 2, 3}, {2})`, "{1, 3}"},
+		{`{1, 2}.union({3}).len()`, 3},
+		{`contains({1, [2]}, [2])`, true},
+		{`{1, 2}.contains(3)`, false},
+		{`contains({1}, [fn() { 1 }])`, false},
+		{`sum({1, 2, 3})`, 6},
+		{`(1, "a")`, "(1, a)"},
+		{`(1,)`, "(1,)"},
+		{`()`, "()"},
+		{`(1, 2)[1]`, 2},
+		{`(1, 2)[-2]`, 1},
+		{`(1, 2)[5]`, nil},
+		{`len((1, 2, 3))`, 3},
+		{`(1, (2, 3)) == (1, (2, 3))`, true},
+		{`(1, 2) == (2, 1)`, false},
+		{`(1, 2).contains(2)`, true},
+		{`(1, 2).to_array() == [1, 2]`, true},
+		{`let h = {(1, 2): "a", (2, 1): "b"}; h[(1, 2)] + h[(2, 1)]`, "ab"},
+		{`let h = {{1, 2}: "set"}; h[{2, 1}]`, "set"},
+		{`{(1, 2), (1, 2), (2, 1)}`, "{(1, 2), (2, 1)}"},
+		{`match (1, 2) { (1, y) => y, _ => 0 }`, 2},
+		{`match (1, 2, 3) { (a, b) => 0, (a, b, c) => a + b + c }`, 6},
+		{`type({1}) + type((1,))`, "SETTUPLE"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong Inspect for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestSetAndTupleErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{1, fn() { 1 }}`, "unusable as set element: FUNCTION"},
+		{`set([{"a": 1}])`, "unusable as set element: HASH"},
+		{`set(1)`, "argument to `set` must be ARRAY, got INTEGER"},
+		{`set([1], [2])`, "wrong number of arguments. got=2, want=0 or 1"},
+		{`union({1}, [1])`, "argument to `union` must be SET, got ARRAY"},
+		{`{(1, fn() { 1 }): 1}`, "unusable as hash key: TUPLE"},
+		{`{1, missing}`, "identifier not found: missing"},
+		{`(1, missing)`, "identifier not found: missing"},
+		{`{1}[0]`, "index operator not supported: SET"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`0..5`, "0..5"},
+		{`let n = 3; 1..=n * 2`, "1..=6"},
+		{`len(0..10)`, 10},
+		{`len(0..=10)`, 11},
+		{`len(5..0)`, 0},
+		{`(0..10)[3]`, 3},
+		{`(0..10)[-1]`, 9},
+		{`(2..=4)[-1]`, 4},
+		{`(0..10)[10]`, nil},
+		{`contains(0..10, 10)`, false},
+		{`contains(0..=10, 10)`, true},
+		{`(0..10).contains("1")`, false},
+		{`(1..4).to_array() == [1, 2, 3]`, true},
+		{`(0..4).map(|x| x * x) == [0, 1, 4, 9]`, true},
+		{`filter(0..10, |x| x > 7) == [8, 9]`, true},
+		{`sum(1..=100)`, 5050},
+		{`reduce(1..=5, 1, |acc, x| acc * x)`, 120},
+		{`take(0..1000000000000, 3) == [0, 1, 2]`, true},
+		{`[1, 2, 3, 4][1..3] == [2, 3]`, true},
+		{`[1, 2, 3, 4][1..=2] == [2, 3]`, true},
+		{`[1, 2, 3, 4][-2..=-1] == [3, 4]`, true},
+		{`"hello"[1..=-1]`, "ello"},
+		{`"hello"[0..2]`, "he"},
+		{`0..=2 == 0..3`, true},
+		{`0..0 == 5..5`, true},
+		{`5..0 == 0..=-1`, true},
+		{`0..0 == 0..=0`, false},
+		{`let h = {0..0: "empty"}; h[5..5]`, "empty"},
+		{`len({0..0, 5..5, 3..=1})`, 1},
+		{`let h = {0..3: "a"}; h[0..=2]`, "a"},
+		{`match 5 { 0..10 => 1, _ => 2 }`, 2},
+		{`len(0..9223372036854775807)`, 9223372036854775807},
+		{`contains(0..=9223372036854775807, 5)`, true},
+		{`contains(0..=9223372036854775807, 9223372036854775807)`, true},
+		{`(0..=9223372036854775807)[-1]`, 9223372036854775807},
+		{`(-9223372036854775807..9223372036854775807)[-9223372036854775807 - 1]`, -1},
+		{`(-9223372036854775807..=9223372036854775807)[-9223372036854775807 - 1]`, 0},
+		{`take(0..=9223372036854775807, 2) == [0, 1]`, true},
+		{`0..=9223372036854775807 == 0..=9223372036854775806`, false},
+		{`"hello"[1..=9223372036854775807]`, "ello"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong Inspect for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`0.."a"`, "range bound must be INTEGER, got STRING"},
+		{`true..1`, "range bound must be INTEGER, got BOOLEAN"},
+		{`0..missing`, "identifier not found: missing"},
+		{`(0..3)["a"]`, "index operator not supported: RANGE"},
+		{`map(1, |x| x)`, "argument to `map` must be ARRAY, got INTEGER"},
+		{`len(-9223372036854775807..9223372036854775807)`, "length of -9223372036854775807..9223372036854775807 does not fit in INTEGER"},
+		{`to_array(0..9223372036854775807)`, "range too large for `to_array`: 0..9223372036854775807"},
+		{`sum(0..=9223372036854775807)`, "range too large for `sum`: 0..=9223372036854775807"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[x * 2 for x in [3, -1, 4] if x > 0] == [6, 8]`, true},
+		{`[x for x in []] == []`, true},
+		{`[x for x in 0..5 if x > 10] == []`, true},
+		{`sum([x * x for x in 1..=3])`, 14},
+		{`[a + b for a, b in [(1, 2), [3, 4]]] == [3, 7]`, true},
+		{`[k for k in {"a": 1, "b": 2}] == ["a", "b"]`, true},
+		{`{k: v * 10 for k, v in {"a": 1, "b": 2}} == {"a": 10, "b": 20}`, true},
+		{`{x: x * x for x in 1..=3}[3]`, 9},
+		{`[x for x in (1, 2)] == [1, 2]`, true},
+		{`len([x for x in {1, 2, 2}])`, 2},
+		{`let gen = fn() { yield 1; yield 2 }; [x * 10 for x in gen()] == [10, 20]`, true},
+		{`[[y * x for y in 1..=2] for x in 1..=2] == [[1, 2], [2, 4]]`, true},
+		{`let fs = [|| i for i in 0..3]; [f() for f in fs] == [0, 1, 2]`, true},
+		{`let x = 100; [x for x in 0..2]; x`, 100},
+		{`let n = 2; [x * n for x in 0..3 if x != n] == [0, 2]`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestComprehensionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`[x for x in 1]`, "cannot iterate over INTEGER"},
+		{`[x for x in missing]`, "identifier not found: missing"},
+		{`[missing for x in [1]]`, "identifier not found: missing"},
+		{`[x for x in [1] if missing]`, "identifier not found: missing"},
+		{`[a for a, b in [1]]`, "cannot unpack INTEGER into 2 loop variables"},
+		{`[a for a, b in [(1, 2, 3)]]`, "wrong number of values to unpack. got=3, want=2"},
+		{`{[fn() { 1 }]: x for x in [1]}`, "unusable as hash key: ARRAY"},
+		{`{x: missing for x in [1]}`, "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`let foobar = 8; quote(unquote(foobar) + 1)`, `(8 + 1)`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote("a" + "b"))`, `ab`},
+		{`quote(unquote(null))`, `null`},
+		{`let q = quote(4 + 4); quote(unquote(4 + 4) + unquote(q))`, `(8 + (4 + 4))`},
+		{`let f = fn(n) { quote(unquote(n) * 2) }; f(1); f(2)`, `(2 * 2)`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Errorf("expected *object.Quote for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("wrong quoted node for %q. expected=%q, got=%q", tt.input, tt.expected, quote.Node.String())
+		}
+	}
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+let number = 1;
+let function = fn(x, y) { x + y };
+let mymacro = macro(x, y) { x + y; };
+`
+
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	for _, name := range []string{"number", "function"} {
+		if _, ok := env.Get(name); ok {
+			t.Errorf("%s should not be defined", name)
+		}
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+
+	if len(macro.Parameters) != 2 || macro.Parameters[0].Value != "x" || macro.Parameters[1].Value != "y" {
+		t.Errorf("wrong macro parameters. got=%v", macro.Parameters)
+	}
+
+	if macro.Body.String() != "(x + y)" {
+		t.Errorf("wrong macro body. got=%q", macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infixExpression = macro() { quote(1 + 2) }; infixExpression();`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)) }; reverse(2 + 2, 10 - 5);`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`let unless = macro(cond, cons, alt) {
+				quote(if (!(unquote(cond))) { unquote(cons) } else { unquote(alt) })
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			`let twice = macro(x) { quote([unquote(x), unquote(x)]) }; let f = fn() { twice(g()) };`,
+			`let f = fn() { [g(), g()] };`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := parser.New(lexer.New(tt.expected)).ParseProgram()
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Errorf("macro expansion of %q failed: %s", tt.input, err.Message)
+			continue
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("wrong expansion. expected=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestMacroErrors(t *testing.T) {
+	expansionTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let m = macro() { 1 }; m()`, "macro m must return QUOTE, got INTEGER"},
+		{`let m = macro(x) { quote(unquote(x)) }; m()`, "wrong number of arguments to macro m. got=0, want=1"},
+		{`let m = macro() { missing }; m()`, "identifier not found: missing"},
+		{`let m = macro() { quote(unquote(fn() { 1 })) }; m()`, "cannot unquote FUNCTION"},
+	}
+
+	for _, tt := range expansionTests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("no expansion error for %q", tt.input)
+			continue
+		}
+
+		if err.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, err.Message)
+		}
+	}
+
+	evalTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`unquote(1)`, "unquote outside quote"},
+		{`quote(1, 2)`, "wrong number of arguments to quote. got=2, want=1"},
+		{`quote(unquote(missing))`, "identifier not found: missing"},
+		{`quote(unquote([1]))`, "cannot unquote ARRAY"},
+		{`let f = fn() { macro(x) { x } }; f()`, "macro must be defined by a top-level let statement"},
+	}
+
+	for _, tt := range evalTests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
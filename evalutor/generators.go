@@ -0,0 +1,246 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"runtime"
+	"sync"
+)
+
+// generatorBuiltins consume generators. Arrays are accepted wherever a finite sequence is expected.
+var generatorBuiltins = map[string]*object.Builtin{
+	"next": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			gen, ok := args[0].(*object.Generator)
+			if !ok {
+				return newError("argument to `next` must be GENERATOR, got %s", args[0].Type())
+			}
+
+			value, ok := gen.Next()
+			if !ok {
+				return NULL
+			}
+
+			return value
+		},
+	},
+	"to_array": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			elements, err := elementsOf("to_array", args[0])
+			if err != nil {
+				return err
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"take": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			next, ok := iterate(args[0])
+			if !ok {
+				return newError("argument to `take` must be ARRAY or GENERATOR, got %s", args[0].Type())
+			}
+
+			count, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `take` must be INTEGER, got %s", args[1].Type())
+			}
+
+			elements := []object.Object{}
+			for int64(len(elements)) < count.Value {
+				value, ok := next()
+				if !ok {
+					break
+				}
+				if isError(value) {
+					return value
+				}
+				elements = append(elements, value)
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range generatorBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// coroutine runs the body of a generator function on its own goroutine.
+// Control passes back and forth with the consumer, so only one side runs at a time.
+// Closing done stops a coroutine parked in Yield, which ends its goroutine.
+type coroutine struct {
+	values chan object.Object
+	resume chan struct{}
+	done   chan struct{}
+}
+
+// Yield hands value to the consumer and waits until the next value is requested.
+// It exits the goroutine instead of returning once the generator is closed.
+func (c *coroutine) Yield(value object.Object) {
+	select {
+	case c.values <- value:
+	case <-c.done:
+		runtime.Goexit()
+	}
+
+	select {
+	case <-c.resume:
+	case <-c.done:
+		runtime.Goexit()
+	}
+}
+
+func (c *coroutine) run(body *ast.BlockStatement, env *object.Environment) {
+	if result := Eval(body, env); isError(result) {
+		c.values <- result
+	}
+	close(c.values)
+}
+
+// newGenerator returns the generator produced by calling the generator function fn with args.
+// The body does not start running until the first value is requested. A generator dropped
+// before it is exhausted is closed once it is garbage collected, or earlier with close.
+func newGenerator(fn *object.Function, args []object.Object) object.Object {
+	env := extendFunctionEnv(fn, args)
+	co := &coroutine{values: make(chan object.Object), resume: make(chan struct{}), done: make(chan struct{})}
+	env.SetYielder(co)
+
+	var mu sync.Mutex
+	started, done := false, false
+	next := func() (object.Object, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if done {
+			return nil, false
+		}
+
+		if !started {
+			started = true
+			go co.run(fn.Body, env)
+		} else {
+			co.resume <- struct{}{}
+		}
+
+		value, ok := <-co.values
+		if !ok || isError(value) {
+			done = true
+		}
+		return value, ok
+	}
+	stop := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !done {
+			done = true
+			close(co.done)
+		}
+	}
+
+	gen := &object.Generator{Next: next, Stop: stop}
+	// The goroutine of the body only refers to co and env, so gen becomes unreachable once
+	// the consumer drops it, even while the body is parked in Yield.
+	runtime.SetFinalizer(gen, func(gen *object.Generator) { gen.Close() })
+	return gen
+}
+
+func evalYieldExpression(node *ast.YieldExpression, env *object.Environment) object.Object {
+	yielder := env.Yielder()
+	if yielder == nil {
+		return newError("yield outside generator")
+	}
+
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	if !node.Delegate {
+		yielder.Yield(value)
+		return NULL
+	}
+
+	next, ok := iterate(value)
+	if !ok {
+		return newError("yield* not supported: %s", value.Type())
+	}
+
+	for {
+		el, ok := next()
+		if !ok {
+			return NULL
+		}
+		if isError(el) {
+			return el
+		}
+		yielder.Yield(el)
+	}
+}
+
+// iterate returns a function producing the elements of an array, tuple, set, range or generator one at a time
+func iterate(obj object.Object) (func() (object.Object, bool), bool) {
+	switch obj := obj.(type) {
+	case *object.Generator:
+		// Calling through obj keeps the generator reachable, so it is not closed while in use
+		return func() (object.Object, bool) { return obj.Next() }, true
+	case *object.Range:
+		return rangeIterator(obj), true
+	default:
+		elements, ok := sequenceOf(obj)
+		if !ok {
+			return nil, false
+		}
+		i := 0
+		return func() (object.Object, bool) {
+			if i >= len(elements) {
+				return nil, false
+			}
+			i++
+			return elements[i-1], true
+		}, true
+	}
+}
+
+// elementsOf returns the elements of an array, tuple or set, the integers of a range,
+// or the values of a generator by running it to the end
+func elementsOf(name string, obj object.Object) ([]object.Object, object.Object) {
+	if elements, ok := sequenceOf(obj); ok {
+		return elements, nil
+	}
+
+	switch obj := obj.(type) {
+	case *object.Range:
+		return rangeElements(name, obj)
+	case *object.Generator:
+		elements := []object.Object{}
+		for {
+			value, ok := obj.Next()
+			if !ok {
+				return elements, nil
+			}
+			if isError(value) {
+				return nil, value
+			}
+			elements = append(elements, value)
+		}
+	default:
+		return nil, newError("argument to `%s` must be ARRAY, got %s", name, obj.Type())
+	}
+}
@@ -0,0 +1,175 @@
+package evalutor
+
+import (
+	"fmt"
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"gtihub.com/yudai2929/monkey-lang/token"
+)
+
+// evalQuote returns the argument of quote(...) unevaluated, after replacing every
+// unquote(...) inside it with the AST node of its evaluated argument
+func evalQuote(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) != 1 {
+		return newError("wrong number of arguments to quote. got=%d, want=1", len(node.Arguments))
+	}
+
+	var unquoteErr object.Object
+	quoted := ast.Modify(node.Arguments[0], func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || !isSpecialForm(call, "unquote") || unquoteErr != nil {
+			return node
+		}
+
+		if len(call.Arguments) != 1 {
+			unquoteErr = newError("wrong number of arguments to unquote. got=%d, want=1", len(call.Arguments))
+			return node
+		}
+
+		unquoted := Eval(call.Arguments[0], env)
+		if isError(unquoted) {
+			unquoteErr = unquoted
+			return node
+		}
+
+		converted, ok := objectToASTNode(unquoted)
+		if !ok {
+			unquoteErr = newError("cannot unquote %s", unquoted.Type())
+			return node
+		}
+		return converted
+	})
+	if unquoteErr != nil {
+		return unquoteErr
+	}
+
+	return &object.Quote{Node: quoted}
+}
+
+// isSpecialForm reports whether call is name(...) for one of the special forms quote and unquote
+func isSpecialForm(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
+
+// objectToASTNode returns the literal node evaluating to obj, or the node of a quote
+func objectToASTNode(obj object.Object) (ast.Node, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.Token{Type: token.INT, Literal: fmt.Sprintf("%d", obj.Value)}
+		return &ast.IntegerLiteral{Token: t, Value: obj.Value}, true
+	case *object.Boolean:
+		t := token.Token{Type: token.FALSE, Literal: "false"}
+		if obj.Value {
+			t = token.Token{Type: token.TRUE, Literal: "true"}
+		}
+		return &ast.Boolean{Token: t, Value: obj.Value}, true
+	case *object.String:
+		t := token.Token{Type: token.STRING, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}, true
+	case *object.Null:
+		return &ast.NullLiteral{Token: token.Token{Type: token.NULL, Literal: "null"}}, true
+	case *object.Quote:
+		return obj.Node, true
+	default:
+		return nil, false
+	}
+}
+
+// DefineMacros binds in env the macros defined by top-level let statements of program,
+// and removes those statements from program
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	statements := program.Statements[:0]
+
+	for _, statement := range program.Statements {
+		let, ok := statement.(*ast.LetStatement)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+
+		macro, ok := let.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+
+		env.Set(let.Name.Value, &object.Macro{Parameters: macro.Parameters, Body: macro.Body, Env: env})
+	}
+
+	program.Statements = statements
+}
+
+// ExpandMacros returns program with every call of a macro bound in env replaced by the
+// node of the quote the macro returns. Macro arguments are passed as quotes of the
+// unevaluated argument expressions. It stops at the first error raised by a macro.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var expansionErr *object.Error
+
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		if expansionErr != nil {
+			return node
+		}
+
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		macro, ok := macroOf(call, env)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != len(macro.Parameters) {
+			expansionErr = newError("wrong number of arguments to macro %s. got=%d, want=%d",
+				call.Function.String(), len(call.Arguments), len(macro.Parameters))
+			return node
+		}
+
+		macroEnv := object.NewEnclosedEnvironment(macro.Env)
+		for i, param := range macro.Parameters {
+			macroEnv.Set(param.Value, &object.Quote{Node: call.Arguments[i]})
+		}
+
+		evaluated := unwrapReturnValue(Eval(macro.Body, macroEnv))
+		if err, ok := evaluated.(*object.Error); ok {
+			expansionErr = err
+			return node
+		}
+
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			expansionErr = newError("macro %s must return QUOTE, got %s", call.Function.String(), typeOf(evaluated))
+			return node
+		}
+
+		return quote.Node
+	})
+
+	return expanded, expansionErr
+}
+
+// macroOf returns the macro called by call, if its function is an identifier bound to one
+func macroOf(call *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
+
+// typeOf returns the type of obj, or NULL for the nil result of a statement
+func typeOf(obj object.Object) object.ObjectType {
+	if obj == nil {
+		return object.NULL_OBJ
+	}
+	return obj.Type()
+}
@@ -0,0 +1,119 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+// evalMatchExpression evaluates the body of the first arm whose pattern matches the subject.
+// Names bound by the pattern are only visible in that arm. Without a matching arm the result is NULL.
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+
+		if matched {
+			return Eval(arm.Body, armEnv)
+		}
+	}
+
+	return NULL
+}
+
+// matchPattern reports whether value matches pattern, binding names into env on the way.
+//
+//   - _ matches anything
+//   - an identifier naming a variant without fields matches that variant, any other identifier binds the value
+//   - Variant(p, ...) and Struct(p, ...) match instances of that declaration whose fields match p, ...
+//   - (p, ...) matches tuples of the same length whose elements match p, ...
+//   - any other expression is evaluated and compared with ==
+func matchPattern(pattern ast.Expression, value object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value == "_" {
+			return true, nil
+		}
+
+		if bound, ok := env.Get(pattern.Value); ok && isUnitVariant(bound, pattern.Value) {
+			return object.Equal(bound, value), nil
+		}
+
+		if err := env.Set(pattern.Value, value); err != nil {
+			return false, newError("%s", err)
+		}
+		return true, nil
+
+	case *ast.CallExpression:
+		constructor := Eval(pattern.Function, env)
+		if isError(constructor) {
+			return false, constructor.(*object.Error)
+		}
+
+		var values []object.Object
+		switch constructor := constructor.(type) {
+		case *object.VariantType:
+			v, ok := value.(*object.Variant)
+			if !ok || v.Definition != constructor {
+				return false, nil
+			}
+			values = v.Values
+		case *object.StructType:
+			s, ok := value.(*object.Struct)
+			if !ok || s.Definition != constructor {
+				return false, nil
+			}
+			values = s.Values
+		default:
+			return false, newError("invalid pattern: %s", pattern.String())
+		}
+
+		if len(pattern.Arguments) != len(values) {
+			return false, newError("wrong number of fields in pattern %s. got=%d, want=%d", pattern.String(), len(pattern.Arguments), len(values))
+		}
+
+		for i, sub := range pattern.Arguments {
+			matched, err := matchPattern(sub, values[i], env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case *ast.TupleLiteral:
+		tuple, ok := value.(*object.Tuple)
+		if !ok || len(tuple.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+
+		for i, sub := range pattern.Elements {
+			matched, err := matchPattern(sub, tuple.Elements[i], env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+
+	default:
+		expected := Eval(pattern, env)
+		if isError(expected) {
+			return false, expected.(*object.Error)
+		}
+		return object.Equal(expected, value), nil
+	}
+}
+
+// isUnitVariant reports whether obj is the instance of a variant without fields that is named name,
+// so that an identifier pattern refers to it rather than binding a new value
+func isUnitVariant(obj object.Object, name string) bool {
+	variant, ok := obj.(*object.Variant)
+	return ok && variant.Definition.Unit == variant && variant.Definition.Name == name
+}
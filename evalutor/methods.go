@@ -0,0 +1,98 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+// methods lists, per receiver type, the builtins that can be called with method syntax.
+// recv.name(args...) calls name(recv, args...).
+var methods = map[object.ObjectType][]string{
+	object.STRING_OBJ: {
+		"len", "split", "trim", "contains", "starts_with", "ends_with",
+		"replace", "upper", "lower", "repeat", "index_of", "substring", "format",
+	},
+	object.ARRAY_OBJ:   {"len", "first", "last", "rest", "push", "contains", "join", "map", "filter", "reduce", "sum"},
+	object.HASH_OBJ:    {"len", "keys", "values", "contains"},
+	object.SET_OBJ:     {"len", "contains", "union", "intersection", "difference", "to_array"},
+	object.TUPLE_OBJ:   {"len", "contains", "to_array"},
+	object.RANGE_OBJ:   {"len", "contains", "take", "to_array", "map", "filter", "reduce", "sum"},
+	object.VARIANT_OBJ: {"tag"},
+	object.GENERATOR_OBJ: {
+		"len", "first", "rest", "next", "take", "to_array", "map", "filter", "reduce", "sum", "close",
+	},
+	object.CHANNEL_OBJ:   {"send", "receive", "close"},
+	object.TASK_OBJ:      {"wait"},
+	object.WAITGROUP_OBJ: {"add", "done", "wait"},
+}
+
+// lookupMethod returns the builtin implementing name for the type of recv
+func lookupMethod(recv object.Object, name string) (*object.Builtin, bool) {
+	for _, method := range methods[recv.Type()] {
+		if method == name {
+			return builtins[name], true
+		}
+	}
+	return nil, false
+}
+
+// lookupField returns the hash entry, struct or variant field, enum variant, or instance member name of recv
+func lookupField(recv object.Object, name string) (object.Object, bool) {
+	switch recv := recv.(type) {
+	case *object.Hash:
+		return recv.Get(&object.String{Value: name})
+	case *object.Struct:
+		return recv.Field(name)
+	case *object.Variant:
+		return recv.Field(name)
+	case *object.EnumType:
+		variant, ok := recv.Variant(name)
+		if !ok {
+			return nil, false
+		}
+		return variantValue(variant), true
+	case *object.Instance:
+		return instanceMember(recv, name)
+	case *object.Super:
+		return superMethod(recv, name)
+	default:
+		return nil, false
+	}
+}
+
+// evalMethodCall evaluates recv.name(args...), reporting whether an optional link of the chain
+// ending in recv skipped the call. piped arguments from a pipeline come before the call's own arguments.
+func evalMethodCall(property *ast.PropertyExpression, piped []object.Object, arguments []ast.Expression, env *object.Environment) (object.Object, bool) {
+	recv, skipped := evalChainLeft(property.Left, property.Optional, env)
+	if skipped || isError(recv) {
+		return recv, skipped
+	}
+
+	return callMethod(recv, property.Property.Value, piped, arguments, env), false
+}
+
+// callMethod calls the method name of recv with piped followed by the values of arguments.
+// A hash entry or struct field holding a function is called as is; otherwise the call
+// dispatches to the method table of the receiver type.
+func callMethod(recv object.Object, name string, piped []object.Object, arguments []ast.Expression, env *object.Environment) object.Object {
+	if field, ok := lookupField(recv, name); ok {
+		args := evalExpressions(arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		return applyFunction(field, append(piped, args...))
+	}
+
+	method, ok := lookupMethod(recv, name)
+	if !ok {
+		return newError("undefined method %s for %s", name, recv.Type())
+	}
+
+	args := evalExpressions(arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	recvArgs := append([]object.Object{recv}, piped...)
+	return applyFunction(method, append(recvArgs, args...))
+}
@@ -0,0 +1,225 @@
+package evalutor
+
+import (
+	"errors"
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/lexer"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"gtihub.com/yudai2929/monkey-lang/parser"
+	"gtihub.com/yudai2929/monkey-lang/stdlib"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ModuleLoader loads Monkey source files imported by path and libraries loaded
+// by name. Each module is evaluated once in its own environment; importers get
+// a hash of its exported bindings.
+//
+// A loader may be used by spawned functions concurrently. Each module imports with the chain
+// of modules that led to it, so cycles are detected per chain; only the caches are shared.
+// Two goroutines loading the same module at once may both evaluate it, the first result is kept.
+type ModuleLoader struct {
+	providers []SourceProvider
+	mu        sync.Mutex              // guards modules and libraries
+	modules   map[string]*object.Hash // files by absolute path
+	libraries map[string]*object.Hash // libraries by name
+}
+
+// NewModuleLoader creates a module loader with an empty cache. Libraries are looked up in
+// providers in order, then in the bundled standard library.
+func NewModuleLoader(providers ...SourceProvider) *ModuleLoader {
+	return &ModuleLoader{
+		providers: append(append([]SourceProvider{}, providers...), &FSProvider{FS: stdlib.Files}),
+		modules:   make(map[string]*object.Hash),
+		libraries: make(map[string]*object.Hash),
+	}
+}
+
+// ImporterFor returns an importer resolving relative paths against dir
+func (ml *ModuleLoader) ImporterFor(dir string) object.Importer {
+	return &moduleImporter{loader: ml, dir: dir}
+}
+
+// Install makes the loader available to scripts evaluated in env: import statements
+// resolve relative to dir and the load builtin looks libraries up in the providers.
+func (ml *ModuleLoader) Install(env *object.Environment, dir string) {
+	ml.install(env, &moduleImporter{loader: ml, dir: dir})
+}
+
+func (ml *ModuleLoader) install(env *object.Environment, importer *moduleImporter) {
+	env.SetImporter(importer)
+	env.Set("load", &object.Builtin{Fn: importer.load})
+}
+
+func (mi *moduleImporter) load(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `load` must be STRING, got %s", args[0].Type())
+	}
+
+	return mi.loader.loadLibrary(name.Value, mi.loading)
+}
+
+// LoadLibrary evaluates the library name from the first provider that has it,
+// or returns the cached namespace if it was already loaded
+func (ml *ModuleLoader) LoadLibrary(name string) object.Object {
+	return ml.loadLibrary(name, nil)
+}
+
+func (ml *ModuleLoader) loadLibrary(name string, loading []string) object.Object {
+	if namespace, ok := ml.cached(ml.libraries, name); ok {
+		return namespace
+	}
+
+	for _, provider := range ml.providers {
+		src, err := provider.ReadSource(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return newError("could not read library %s: %s", name, err)
+		}
+
+		return ml.cache(ml.libraries, name, ml.evalModule(name, src, "", loading))
+	}
+
+	return newError("could not find library %s", name)
+}
+
+// Load evaluates the module at path, or returns the cached namespace if it was already loaded
+func (ml *ModuleLoader) Load(path string) object.Object {
+	return ml.loadFile(path, nil)
+}
+
+func (ml *ModuleLoader) loadFile(path string, loading []string) object.Object {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return newError("could not resolve module %s: %s", path, err)
+	}
+
+	if namespace, ok := ml.cached(ml.modules, path); ok {
+		return namespace
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return newError("could not read module %s: %s", path, err)
+	}
+
+	return ml.cache(ml.modules, path, ml.evalModule(path, string(src), filepath.Dir(path), loading))
+}
+
+func (ml *ModuleLoader) cached(cache map[string]*object.Hash, key string) (*object.Hash, bool) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	namespace, ok := cache[key]
+	return namespace, ok
+}
+
+// cache stores a successfully loaded namespace under key, unless another load stored one first
+func (ml *ModuleLoader) cache(cache map[string]*object.Hash, key string, namespace object.Object) object.Object {
+	hash, ok := namespace.(*object.Hash)
+	if !ok {
+		return namespace
+	}
+
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	if existing, ok := cache[key]; ok {
+		return existing
+	}
+	cache[key] = hash
+	return hash
+}
+
+// evalModule evaluates the source of the module named name in a fresh environment
+// whose imports resolve against dir, and returns the hash of its exports.
+// loading holds the modules whose evaluation led to this one, outermost first.
+func (ml *ModuleLoader) evalModule(name, src, dir string, loading []string) object.Object {
+	for i, outer := range loading {
+		if outer == name {
+			cycle := append(append([]string{}, loading[i:]...), name)
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("could not parse module %s: %s", name, strings.Join(p.Errors(), "; "))
+	}
+
+	env := object.NewEnvironment()
+	chain := append(append([]string{}, loading...), name)
+	ml.install(env, &moduleImporter{loader: ml, dir: dir, loading: chain})
+
+	macros := object.NewEnvironment()
+	DefineMacros(program, macros)
+	expanded, err := ExpandMacros(program, macros)
+	if err != nil {
+		return err
+	}
+
+	if result := Eval(expanded, env); isError(result) {
+		return result
+	}
+
+	namespace := object.NewHash()
+	for _, export := range env.Exports() {
+		value, _ := env.Get(export)
+		namespace.Set(&object.String{Value: export}, value)
+	}
+
+	return namespace
+}
+
+type moduleImporter struct {
+	loader  *ModuleLoader
+	dir     string
+	loading []string // the chain of modules being evaluated that this importer belongs to
+}
+
+// Import loads the module at path, relative to the directory of the importing module
+func (mi *moduleImporter) Import(path string) object.Object {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(mi.dir, path)
+	}
+	return mi.loader.loadFile(path, mi.loading)
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	importer := env.Importer()
+	if importer == nil {
+		return newError("import not supported: no module loader configured")
+	}
+
+	namespace := importer.Import(node.Path.Value)
+	if isError(namespace) {
+		return namespace
+	}
+
+	if err := env.Set(node.Alias.Value, namespace); err != nil {
+		return newError("%s", err)
+	}
+
+	return nil
+}
+
+func evalExportStatement(node *ast.ExportStatement, env *object.Environment) object.Object {
+	if result := Eval(node.Declaration, env); isError(result) {
+		return result
+	}
+
+	env.Export(node.Declaration.Name.Value)
+
+	return nil
+}
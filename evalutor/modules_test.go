@@ -0,0 +1,286 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/lexer"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"gtihub.com/yudai2929/monkey-lang/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestModuleLoaderEvaluatesExports(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.mk": `
+			import "lib/math.mk" as math;
+			export let result = math.square(math.offset);
+			export let hiddenVisible = math.hidden ?? "no";
+		`,
+		"lib/math.mk": `
+			let hidden = 1;
+			export let offset = 3;
+			export let square = fn(x) { x * x + hidden - 1 };
+		`,
+	})
+
+	loader := NewModuleLoader()
+	namespace, ok := loader.Load(filepath.Join(dir, "main.mk")).(*object.Hash)
+	if !ok {
+		t.Fatalf("Load didn't return Hash. got=%s", loader.Load(filepath.Join(dir, "main.mk")).Inspect())
+	}
+
+	result, _ := namespace.Get(&object.String{Value: "result"})
+	testIntegerObject(t, result, 9)
+
+	hidden, _ := namespace.Get(&object.String{Value: "hiddenVisible"})
+	testStringObject(t, hidden, "no")
+
+	if namespace.Len() != 2 {
+		t.Errorf("namespace exposes unexported bindings. got=%s", namespace.Inspect())
+	}
+}
+
+func TestModuleLoaderResolvesRelativeToImporter(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.mk":         `import "lib/a.mk" as a; export let value = a.value;`,
+		"lib/a.mk":        `import "nested/b.mk" as b; export let value = b.value + 1;`,
+		"lib/nested/b.mk": `export let value = 41;`,
+	})
+
+	namespace := NewModuleLoader().Load(filepath.Join(dir, "main.mk"))
+	if isError(namespace) {
+		t.Fatalf("Load returned error: %s", namespace.Inspect())
+	}
+
+	value, _ := namespace.(*object.Hash).Get(&object.String{Value: "value"})
+	testIntegerObject(t, value, 42)
+}
+
+func TestModuleLoaderCachesModules(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a.mk": `export let value = 1;`,
+	})
+
+	loader := NewModuleLoader()
+	first := loader.Load(filepath.Join(dir, "a.mk"))
+	second := loader.Load(filepath.Join(dir, ".", "a.mk"))
+
+	if first != second {
+		t.Errorf("module evaluated twice")
+	}
+}
+
+func TestModuleLoaderExpandsMacros(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.mk": `
+			let unless = macro(cond, cons, alt) { quote(if (!(unquote(cond))) { unquote(cons) } else { unquote(alt) }) };
+			export let value = unless(1 > 2, "expanded", "not expanded");
+		`,
+	})
+
+	namespace := NewModuleLoader().Load(filepath.Join(dir, "main.mk"))
+	if isError(namespace) {
+		t.Fatalf("Load returned error: %s", namespace.Inspect())
+	}
+
+	value, _ := namespace.(*object.Hash).Get(&object.String{Value: "value"})
+	testStringObject(t, value, "expanded")
+}
+
+func TestModuleLoaderErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"cycle_a.mk": `import "cycle_b.mk" as b;`,
+		"cycle_b.mk": `import "cycle_a.mk" as a;`,
+		"broken.mk":  `let = 1;`,
+		"failing.mk": `export let x = 1 + true;`,
+		"missing.mk": `import "nope.mk" as nope;`,
+		"macro.mk":   `let m = macro() { 1 }; m();`,
+	})
+
+	tests := []struct {
+		file            string
+		expectedMessage string
+	}{
+		{"cycle_a.mk", "import cycle: " + filepath.Join(dir, "cycle_a.mk") + " -> " + filepath.Join(dir, "cycle_b.mk") + " -> " + filepath.Join(dir, "cycle_a.mk")},
+		{"broken.mk", "could not parse module " + filepath.Join(dir, "broken.mk") + ": expected next token to be IDENT, got = instead"},
+		{"failing.mk", "type mismatch: INTEGER + BOOLEAN"},
+		{"missing.mk", "could not read module " + filepath.Join(dir, "nope.mk")},
+		{"macro.mk", "macro m must return QUOTE, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := NewModuleLoader().Load(filepath.Join(dir, tt.file))
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if !strings.HasPrefix(errObj.Message, tt.expectedMessage) {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestImportWithoutLoader(t *testing.T) {
+	evaluated := testEval(`import "a.mk" as a;`)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "import not supported: no module loader configured" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+// testEvalWithLoader evaluates input in an environment with loader installed
+func testEvalWithLoader(loader *ModuleLoader, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	loader.Install(env, ".")
+
+	return Eval(program, env)
+}
+
+func TestLoadFromProviders(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib/disk.mk": `export let value = 2;`,
+	})
+
+	memory := MapProvider{
+		"app/config": `export let port = 8080;`,
+		"app/uses":   `let cfg = load("app/config"); export let next = cfg.port + 1;`,
+		"std/list":   `export let reverse = fn(xs) { "overridden" };`,
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`load("app/config").port`, 8080},
+		{`load("app/uses").next`, 8081},
+		{`load("lib/disk").value`, 2},
+		{`load("lib/disk.mk").value`, 2},
+		{`load("std/list").reverse([1, 2])`, "overridden"},
+		{`load("std/strings").capitalize("monkey")`, "Monkey"},
+	}
+
+	for _, tt := range tests {
+		loader := NewModuleLoader(memory, NewDirProvider(dir))
+		evaluated := testEvalWithLoader(loader, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestLoadCachesLibraries(t *testing.T) {
+	loader := NewModuleLoader(MapProvider{"lib": `export let value = 1;`})
+
+	first := loader.LoadLibrary("lib")
+	second := testEvalWithLoader(loader, `load("lib")`)
+
+	if first != second {
+		t.Errorf("library evaluated twice")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`load("nope")`, "could not find library nope"},
+		{`load(1)`, "argument to `load` must be STRING, got INTEGER"},
+		{`load("cycle/a")`, "import cycle: cycle/a -> cycle/b -> cycle/a"},
+		{`load("broken")`, "could not parse module broken: expected next token to be IDENT, got = instead; no prefix parse function for = found"},
+	}
+
+	loader := NewModuleLoader(MapProvider{
+		"cycle/a": `load("cycle/b");`,
+		"cycle/b": `load("cycle/a");`,
+		"broken":  `let = 1;`,
+	})
+
+	for _, tt := range tests {
+		evaluated := testEvalWithLoader(loader, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+
+	evaluated := testEval(`load("std/list")`)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "load not supported: no module loader configured" {
+		t.Errorf("load without a loader did not fail. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestStandardLibrary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let list = load("std/list"); list.range(4)`, "[[0 1 2 3]]"},
+		{`let list = load("std/list"); list.reverse([1, 2, 3])`, "[[3 2 1]]"},
+		{`let list = load("std/list"); list.take([1, 2, 3], 2)`, "[[1 2]]"},
+		{`let list = load("std/list"); list.drop([1, 2, 3], 2)`, "[[3]]"},
+		{`let list = load("std/list"); list.find([1, 2, 3], |x| x > 1)`, "2"},
+		{`let list = load("std/list"); list.any([1, 2, 3], |x| x > 2)`, "true"},
+		{`let list = load("std/list"); list.all([1, 2, 3], |x| x > 2)`, "false"},
+		{`let list = load("std/list"); list.flatten([[1], [2, 3], []])`, "[[1 2 3]]"},
+		{`let list = load("std/list"); list.zip([1, 2, 3], ["a", "b"])`, "[[[[1 a]] [[2 b]]]]"},
+		{`let s = load("std/strings"); s.capitalize("monkey")`, "Monkey"},
+		{`let s = load("std/strings"); s.pad_left("7", 3, "0")`, "007"},
+		{`let s = load("std/strings"); s.pad_right("ab", 4, ".")`, "ab.."},
+		{`let s = load("std/strings"); s.words("  a  b c ")`, "[[a b c]]"},
+		{`let s = load("std/strings"); s.is_empty("")`, "true"},
+		{`let s = load("std/strings"); s.reverse("abc")`, "cba"},
+		{`let f = load("std/functional"); f.compose(|x| x + 1, |x| x * 2)(5)`, "11"},
+		{`let f = load("std/functional"); f.pipe([|x| x + 1, |x| x * 2])(5)`, "12"},
+		{`let f = load("std/functional"); f.partial(|a, b| a - b, 10)(3)`, "7"},
+		{`let f = load("std/functional"); f.flip(|a, b| a - b)(10, 3)`, "-7"},
+		{`let f = load("std/functional"); f.constant(3)()`, "3"},
+		{`let f = load("std/functional"); f.identity("x")`, "x"},
+		{`let f = load("std/functional"); f.times(3, |i| i * i)`, "[[0 1 4]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithLoader(NewModuleLoader(), tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+// operatorMethods maps infix operators to the handler that overloads them
+var operatorMethods = map[string]string{
+	"+":  "__add__",
+	"-":  "__sub__",
+	"*":  "__mul__",
+	"/":  "__div__",
+	"==": "__eq__",
+	"!=": "__ne__",
+	"<":  "__lt__",
+	">":  "__gt__",
+}
+
+// operatorHandler returns the handler name defined by obj along with the arguments it is called with first.
+// Hashes hold handlers as entries that take the hash itself as first argument;
+// instances define them as methods, which see the receiver as self.
+func operatorHandler(obj object.Object, name string) (object.Object, []object.Object, bool) {
+	switch obj := obj.(type) {
+	case *object.Hash:
+		handler, ok := obj.Get(&object.String{Value: name})
+		return handler, []object.Object{obj}, ok
+	case *object.Instance:
+		handler, ok := instanceMember(obj, name)
+		return handler, nil, ok
+	default:
+		return nil, nil, false
+	}
+}
+
+// callOperator calls the handler name of recv with args, reporting whether recv defines it
+func callOperator(recv object.Object, name string, args ...object.Object) (object.Object, bool) {
+	handler, leading, ok := operatorHandler(recv, name)
+	if !ok {
+		return nil, false
+	}
+
+	return applyFunction(handler, append(leading, args...)), true
+}
+
+// evalOverloadedInfixExpression evaluates left operator right with the handler of left.
+// Comparisons also consult the right operand: == and != with __eq__, < and > with the mirrored handler.
+// != falls back to the negated __eq__ when no __ne__ is defined.
+func evalOverloadedInfixExpression(operator string, left, right object.Object) (object.Object, bool) {
+	name, ok := operatorMethods[operator]
+	if !ok {
+		return nil, false
+	}
+
+	if result, ok := callOperator(left, name, right); ok {
+		return result, true
+	}
+
+	switch operator {
+	case "==":
+		return callOperator(right, "__eq__", left)
+	case "!=":
+		result, ok := callOperator(left, "__eq__", right)
+		if !ok {
+			result, ok = callOperator(right, "__eq__", left)
+		}
+		if !ok || isError(result) {
+			return result, ok
+		}
+		return nativeBoolToBooleanObject(!isTruthy(result)), true
+	case "<":
+		return callOperator(right, "__gt__", left)
+	case ">":
+		return callOperator(right, "__lt__", left)
+	}
+
+	return nil, false
+}
+
+// stringify returns the string form of obj used by templates, str, puts and format,
+// calling its __str__ handler when it defines one
+func stringify(obj object.Object) (string, *object.Error) {
+	result, ok := callOperator(obj, "__str__")
+	if !ok {
+		return obj.Inspect(), nil
+	}
+
+	switch result := result.(type) {
+	case *object.Error:
+		return "", result
+	case *object.String:
+		return result.Value, nil
+	default:
+		return "", newError("__str__ must return STRING, got %s", result.Type())
+	}
+}
@@ -0,0 +1,53 @@
+package evalutor
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// SourceProvider supplies the Monkey source of libraries loaded with load("name").
+// ReadSource returns an error wrapping fs.ErrNotExist when it has no library of that name.
+type SourceProvider interface {
+	ReadSource(name string) (string, error)
+}
+
+// libraryFile returns the file name of the library name, e.g. std/list.mk for std/list
+func libraryFile(name string) string {
+	if strings.HasSuffix(name, ".mk") {
+		return name
+	}
+	return name + ".mk"
+}
+
+// FSProvider serves libraries from a file system such as an embed.FS
+type FSProvider struct {
+	FS fs.FS
+}
+
+// ReadSource reads the library name from the file system
+func (p *FSProvider) ReadSource(name string) (string, error) {
+	src, err := fs.ReadFile(p.FS, path.Clean(libraryFile(name)))
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+// NewDirProvider creates a provider serving libraries from the directory dir
+func NewDirProvider(dir string) *FSProvider {
+	return &FSProvider{FS: os.DirFS(dir)}
+}
+
+// MapProvider serves libraries from memory, keyed by library name
+type MapProvider map[string]string
+
+// ReadSource returns the library name from the map
+func (p MapProvider) ReadSource(name string) (string, error) {
+	src, ok := p[name]
+	if !ok {
+		return "", &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return src, nil
+}
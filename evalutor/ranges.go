@@ -0,0 +1,94 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"math"
+)
+
+// maxRangeElements caps the number of integers a range is expanded into by builtins such as
+// to_array and map, which need every element at once
+const maxRangeElements = 1 << 26
+
+func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) object.Object {
+	var bounds [2]int64
+	for i, boundNode := range []ast.Expression{node.Start, node.End} {
+		bound := Eval(boundNode, env)
+		if isError(bound) {
+			return bound
+		}
+
+		integer, ok := bound.(*object.Integer)
+		if !ok {
+			return newError("range bound must be INTEGER, got %s", bound.Type())
+		}
+		bounds[i] = integer.Value
+	}
+
+	return &object.Range{Start: bounds[0], End: bounds[1], Inclusive: node.Inclusive}
+}
+
+func evalRangeIndexExpression(rng, index object.Object) object.Object {
+	rangeObject := rng.(*object.Range)
+	idx := index.(*object.Integer).Value
+	n := rangeObject.Len()
+
+	// The length of a range may not fit in an int64, so negative indices are resolved in uint64.
+	// -idx wraps to math.MinInt64 for idx == math.MinInt64, which is still 2^63 as a uint64.
+	var pos uint64
+	if idx < 0 {
+		back := uint64(-idx)
+		if back > n {
+			return NULL
+		}
+		pos = n - back
+	} else {
+		pos = uint64(idx)
+		if pos >= n {
+			return NULL
+		}
+	}
+
+	return &object.Integer{Value: rangeObject.At(pos)}
+}
+
+// rangeElements returns the integers of rng as objects. It fails for ranges longer than
+// maxRangeElements instead of attempting an allocation the host cannot make.
+func rangeElements(name string, rng *object.Range) ([]object.Object, object.Object) {
+	if rng.Len() > maxRangeElements {
+		return nil, newError("range too large for `%s`: %s", name, rng.Inspect())
+	}
+
+	elements := make([]object.Object, rng.Len())
+	for i := range elements {
+		elements[i] = &object.Integer{Value: rng.At(uint64(i))}
+	}
+	return elements, nil
+}
+
+// sliceByRange returns the part of an array or string spanned by rng, so xs[1..3] is xs[1:3].
+// Negative bounds count from the end as in slices, and xs[i..=-1] runs to the last element.
+func sliceByRange(left object.Object, rng *object.Range) object.Object {
+	start, end := rng.Start, rng.End
+	if !rng.Inclusive {
+		return sliceObject(left, &start, &end, nil)
+	}
+
+	if end == -1 || end == math.MaxInt64 {
+		return sliceObject(left, &start, nil, nil)
+	}
+	end++
+	return sliceObject(left, &start, &end, nil)
+}
+
+// rangeIterator returns a function producing the integers of rng one at a time
+func rangeIterator(rng *object.Range) func() (object.Object, bool) {
+	var i uint64
+	return func() (object.Object, bool) {
+		if i >= rng.Len() {
+			return nil, false
+		}
+		i++
+		return &object.Integer{Value: rng.At(i - 1)}, true
+	}
+}
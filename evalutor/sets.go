@@ -0,0 +1,97 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+// setBuiltins build and combine sets. The set operations always return a new set.
+var setBuiltins = map[string]*object.Builtin{
+	"set": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			if len(args) == 0 {
+				return object.NewSet()
+			}
+
+			elements, err := elementsOf("set", args[0])
+			if err != nil {
+				return err
+			}
+
+			return newSet(elements)
+		},
+	},
+	"union":        setOperation("union", (*object.Set).Union),
+	"intersection": setOperation("intersection", (*object.Set).Intersection),
+	"difference":   setOperation("difference", (*object.Set).Difference),
+}
+
+func init() {
+	for name, builtin := range setBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// setOperation returns a builtin applying op to two sets
+func setOperation(name string, op func(*object.Set, *object.Set) *object.Set) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			left, ok := args[0].(*object.Set)
+			if !ok {
+				return newError("argument to `%s` must be SET, got %s", name, args[0].Type())
+			}
+
+			right, ok := args[1].(*object.Set)
+			if !ok {
+				return newError("argument to `%s` must be SET, got %s", name, args[1].Type())
+			}
+
+			return op(left, right)
+		},
+	}
+}
+
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+
+	return newSet(elements)
+}
+
+// newSet returns a set of elements, or an error if one of them is not hashable
+func newSet(elements []object.Object) object.Object {
+	set := object.NewSet()
+	for _, el := range elements {
+		hashable, ok := object.AsHashable(el)
+		if !ok {
+			return newError("unusable as set element: %s", el.Type())
+		}
+		set.Add(hashable)
+	}
+
+	return set
+}
+
+// sequenceOf returns the elements of an array, tuple or set
+func sequenceOf(obj object.Object) ([]object.Object, bool) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Elements, true
+	case *object.Tuple:
+		return obj.Elements, true
+	case *object.Set:
+		return obj.Elements(), true
+	default:
+		return nil, false
+	}
+}
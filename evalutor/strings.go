@@ -0,0 +1,272 @@
+package evalutor
+
+import (
+	"bytes"
+	"gtihub.com/yudai2929/monkey-lang/object"
+	"strings"
+)
+
+// stringBuiltins is the string module. Every function takes the string it works on as its first argument.
+var stringBuiltins = map[string]*object.Builtin{
+	"split": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("split", args, 2); err != nil {
+				return err
+			}
+
+			parts := strings.Split(args[0].(*object.String).Value, args[1].(*object.String).Value)
+			elements := make([]object.Object, len(parts))
+			for i, part := range parts {
+				elements[i] = &object.String{Value: part}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"join": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `join` must be ARRAY, got %s", args[0].Type())
+			}
+
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newError("argument to `join` must be STRING, got %s", args[1].Type())
+			}
+
+			parts := make([]string, len(arr.Elements))
+			for i, el := range arr.Elements {
+				str, ok := el.(*object.String)
+				if !ok {
+					return newError("elements of `join` must be STRING, got %s", el.Type())
+				}
+				parts[i] = str.Value
+			}
+
+			return &object.String{Value: strings.Join(parts, sep.Value)}
+		},
+	},
+	"trim": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("trim", args, 1); err != nil {
+				return err
+			}
+
+			return &object.String{Value: strings.TrimSpace(args[0].(*object.String).Value)}
+		},
+	},
+	"starts_with": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("starts_with", args, 2); err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(strings.HasPrefix(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		},
+	},
+	"ends_with": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("ends_with", args, 2); err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(strings.HasSuffix(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		},
+	},
+	"replace": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("replace", args, 3); err != nil {
+				return err
+			}
+
+			str := args[0].(*object.String).Value
+			old := args[1].(*object.String).Value
+			replacement := args[2].(*object.String).Value
+
+			return &object.String{Value: strings.ReplaceAll(str, old, replacement)}
+		},
+	},
+	"upper": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("upper", args, 1); err != nil {
+				return err
+			}
+
+			return &object.String{Value: strings.ToUpper(args[0].(*object.String).Value)}
+		},
+	},
+	"lower": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("lower", args, 1); err != nil {
+				return err
+			}
+
+			return &object.String{Value: strings.ToLower(args[0].(*object.String).Value)}
+		},
+	},
+	"repeat": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `repeat` must be STRING, got %s", args[0].Type())
+			}
+
+			count, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `repeat` must be INTEGER, got %s", args[1].Type())
+			}
+
+			return repeatString(str, count)
+		},
+	},
+	"index_of": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkStringArgs("index_of", args, 2); err != nil {
+				return err
+			}
+
+			return &object.Integer{Value: int64(strings.Index(args[0].(*object.String).Value, args[1].(*object.String).Value))}
+		},
+	},
+	"substring": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `substring` must be STRING, got %s", args[0].Type())
+			}
+
+			start, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `substring` must be INTEGER, got %s", args[1].Type())
+			}
+
+			end, ok := args[2].(*object.Integer)
+			if !ok {
+				return newError("argument to `substring` must be INTEGER, got %s", args[2].Type())
+			}
+
+			if start.Value < 0 || end.Value > int64(len(str.Value)) || start.Value > end.Value {
+				return newError("substring out of range: [%d:%d] with length %d", start.Value, end.Value, len(str.Value))
+			}
+
+			return &object.String{Value: str.Value[start.Value:end.Value]}
+		},
+	},
+	"format": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, want at least 1", len(args))
+			}
+
+			format, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `format` must be STRING, got %s", args[0].Type())
+			}
+
+			return formatString(format.Value, args[1:])
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range stringBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// checkStringArgs checks that args holds exactly want strings
+func checkStringArgs(name string, args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+
+	for _, arg := range args {
+		if arg.Type() != object.STRING_OBJ {
+			return newError("argument to `%s` must be STRING, got %s", name, arg.Type())
+		}
+	}
+
+	return nil
+}
+
+// maxStringLength caps the length of the strings built by repeat, so that a large count
+// fails with an error instead of exhausting the memory of the host
+const maxStringLength = 1 << 30
+
+func repeatString(str *object.String, count *object.Integer) object.Object {
+	if count.Value < 0 {
+		return newError("negative repeat count: %d", count.Value)
+	}
+
+	if len(str.Value) > 0 && count.Value > int64(maxStringLength/len(str.Value)) {
+		return newError("repeat count too large: %d", count.Value)
+	}
+
+	return &object.String{Value: strings.Repeat(str.Value, int(count.Value))}
+}
+
+// formatString expands the verbs in format with args.
+// %s inserts any value as its template string form, %d requires an INTEGER and %% is a literal percent sign.
+func formatString(format string, args []object.Object) object.Object {
+	var out bytes.Buffer
+	argIdx := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		if i+1 >= len(format) {
+			return newError("format: trailing %% in %q", format)
+		}
+
+		i++
+		verb := format[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if argIdx >= len(args) {
+			return newError("format: missing argument for %%%c", verb)
+		}
+		arg := args[argIdx]
+		argIdx++
+
+		switch verb {
+		case 's':
+			str, err := stringify(arg)
+			if err != nil {
+				return err
+			}
+			out.WriteString(str)
+		case 'd':
+			if arg.Type() != object.INTEGER_OBJ {
+				return newError("format: %%d expects INTEGER, got %s", arg.Type())
+			}
+			out.WriteString(arg.Inspect())
+		default:
+			return newError("format: unknown verb %%%c", verb)
+		}
+	}
+
+	if argIdx != len(args) {
+		return newError("format: too many arguments. got=%d, used=%d", len(args), argIdx)
+	}
+
+	return &object.String{Value: out.String()}
+}
@@ -0,0 +1,40 @@
+package evalutor
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"gtihub.com/yudai2929/monkey-lang/object"
+)
+
+func evalStructStatement(node *ast.StructStatement, env *object.Environment) object.Object {
+	definition := &object.StructType{Name: node.Name.Value}
+	for _, field := range node.Fields {
+		definition.Fields = append(definition.Fields, field.Value)
+	}
+
+	if err := env.Set(node.Name.Value, definition); err != nil {
+		return newError("%s", err)
+	}
+
+	return nil
+}
+
+// newStruct constructs an instance of definition from positional field values
+func newStruct(definition *object.StructType, args []object.Object) object.Object {
+	if len(args) != len(definition.Fields) {
+		return newError("wrong number of fields for %s. got=%d, want=%d", definition.Name, len(args), len(definition.Fields))
+	}
+
+	values := make([]object.Object, len(args))
+	copy(values, args)
+
+	return &object.Struct{Definition: definition, Values: values}
+}
+
+// structField returns the field name of s, failing on unknown fields
+func structField(s *object.Struct, name string) object.Object {
+	value, ok := s.Field(name)
+	if !ok {
+		return newError("unknown field %s for %s", name, s.Definition.Name)
+	}
+	return value
+}
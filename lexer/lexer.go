@@ -7,6 +7,10 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+
+	// templateDepth holds, for each template expression being lexed, how many
+	// braces are open inside it. A '}' seen at depth 0 resumes the template string.
+	templateDepth []int
 }
 
 // New creates a new Lexer instance
@@ -48,6 +52,9 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.FAT_ARROW, Literal: "=>"}
 		} else {
 			tok = token.New(token.ASSIGN, l.ch)
 		}
@@ -71,6 +78,27 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.New(token.LT, l.ch)
 	case '>':
 		tok = token.New(token.GT, l.ch)
+	case '|':
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.PIPELINE, Literal: "|>"}
+		} else {
+			tok = token.New(token.PIPE, l.ch)
+		}
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL_DOT, Literal: "?."}
+		case '[':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL_LBRACK, Literal: "?["}
+		default:
+			tok = token.New(token.ILLEGAL, l.ch)
+		}
 	case ';':
 		tok = token.New(token.SEMICOLON, l.ch)
 	case '(':
@@ -80,18 +108,50 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = token.New(token.COMMA, l.ch)
 	case '{':
+		if n := len(l.templateDepth); n > 0 {
+			l.templateDepth[n-1]++
+		}
 		tok = token.New(token.LBRACE, l.ch)
 	case '}':
-		tok = token.New(token.RBRACE, l.ch)
+		if n := len(l.templateDepth); n > 0 && l.templateDepth[n-1] == 0 {
+			l.templateDepth = l.templateDepth[:n-1]
+			literal, interpolated := l.readStringPart()
+			if interpolated {
+				tok = token.Token{Type: token.TEMPLATE_MIDDLE, Literal: literal}
+			} else {
+				tok = token.Token{Type: token.TEMPLATE_TAIL, Literal: literal}
+			}
+		} else {
+			if n > 0 {
+				l.templateDepth[n-1]--
+			}
+			tok = token.New(token.RBRACE, l.ch)
+		}
 	case '[':
 		tok = token.New(token.LBRACK, l.ch)
 	case ']':
 		tok = token.New(token.RBRACK, l.ch)
 	case ':':
 		tok = token.New(token.COLON, l.ch)
+	case '.':
+		if l.peekChar() == '.' {
+			l.readChar()
+			if l.peekChar() == '=' {
+				l.readChar()
+				tok = token.Token{Type: token.RANGE_INCLUSIVE, Literal: "..="}
+			} else {
+				tok = token.Token{Type: token.RANGE, Literal: ".."}
+			}
+		} else {
+			tok = token.New(token.DOT, l.ch)
+		}
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		literal, interpolated := l.readStringPart()
+		if interpolated {
+			tok = token.Token{Type: token.TEMPLATE_HEAD, Literal: literal}
+		} else {
+			tok = token.Token{Type: token.STRING, Literal: literal}
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -122,16 +182,22 @@ func (l *Lexer) readIdentifier() string {
 
 }
 
-// readString reads a string from the input
-func (l *Lexer) readString() string {
+// readStringPart reads a string from the input up to the closing quote or the next ${.
+// It reports whether it stopped at ${, in which case the lexer goes on with the embedded expression.
+func (l *Lexer) readStringPart() (string, bool) {
 	position := l.position + 1
 	for {
 		l.readChar()
 		if l.ch == '"' || l.ch == 0 {
-			break
+			return l.input[position:l.position], false
+		}
+		if l.ch == '$' && l.peekChar() == '{' {
+			literal := l.input[position:l.position]
+			l.readChar()
+			l.templateDepth = append(l.templateDepth, 0)
+			return literal, true
 		}
 	}
-	return l.input[position:l.position]
 }
 
 // isLetter checks if a character is a letter
@@ -136,3 +136,83 @@ if (5 < 10) {
 	}
 
 }
+
+func TestLexer_NextToken_TemplateLiteral(t *testing.T) {
+	input := `"Hello ${name}, you are ${age + 1}" "${ {"a": 1}["a"] }" "a ${"b ${c}"}"`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_HEAD, "Hello "},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_MIDDLE, ", you are "},
+		{token.IDENT, "age"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.TEMPLATE_HEAD, ""},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACK, "["},
+		{token.STRING, "a"},
+		{token.RBRACK, "]"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.TEMPLATE_HEAD, "a "},
+		{token.TEMPLATE_HEAD, "b "},
+		{token.IDENT, "c"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.TEMPLATE_TAIL, ""},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equal(t, tt.expectedType, tok.Type, "tests[%d] - tokentype wrong", i)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal, "tests[%d] - literal wrong", i)
+	}
+}
+
+func TestLexer_NextToken_Operators(t *testing.T) {
+	input := `null ?? a?.b?[0] |> ? | => 0..n 1..=2`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.NULL, "null"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "a"},
+		{token.OPTIONAL_DOT, "?."},
+		{token.IDENT, "b"},
+		{token.OPTIONAL_LBRACK, "?["},
+		{token.INT, "0"},
+		{token.RBRACK, "]"},
+		{token.PIPELINE, "|>"},
+		{token.ILLEGAL, "?"},
+		{token.PIPE, "|"},
+		{token.FAT_ARROW, "=>"},
+		{token.INT, "0"},
+		{token.RANGE, ".."},
+		{token.IDENT, "n"},
+		{token.INT, "1"},
+		{token.RANGE_INCLUSIVE, "..="},
+		{token.INT, "2"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equal(t, tt.expectedType, tok.Type, "tests[%d] - tokentype wrong", i)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal, "tests[%d] - literal wrong", i)
+	}
+}
@@ -2,12 +2,18 @@ package main
 
 import (
 	"fmt"
+	"gtihub.com/yudai2929/monkey-lang/evalutor"
+	"gtihub.com/yudai2929/monkey-lang/object"
 	"gtihub.com/yudai2929/monkey-lang/repl"
 	"os"
 	"os/user"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		runFile(os.Args[1])
+		return
+	}
 
 	u, err := user.Current()
 	if err != nil {
@@ -20,3 +26,12 @@ func main() {
 		panic(err)
 	}
 }
+
+// runFile evaluates the script at path as the entry module
+func runFile(path string) {
+	result := evalutor.NewModuleLoader().Load(path)
+	if errObj, ok := result.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, errObj.Inspect())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,133 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// Class is a class declaration. Calling it constructs an Instance and runs its init method.
+type Class struct {
+	Name       string
+	Superclass *Class
+	Methods    map[string]*Function
+}
+
+// Type returns the type of the object
+func (c *Class) Type() ObjectType { return CLASS_OBJ }
+
+// Inspect returns the string representation of the object
+func (c *Class) Inspect() string {
+	if c.Superclass == nil {
+		return "class " + c.Name
+	}
+	return "class " + c.Name + " extends " + c.Superclass.Name
+}
+
+// Method looks name up on the class and then on its superclasses.
+// It also returns the class that defines the method.
+func (c *Class) Method(name string) (*Function, *Class, bool) {
+	for class := c; class != nil; class = class.Superclass {
+		if method, ok := class.Methods[name]; ok {
+			return method, class, true
+		}
+	}
+	return nil, nil, false
+}
+
+// IsSubclassOf reports whether c is other or inherits from it
+func (c *Class) IsSubclassOf(other *Class) bool {
+	for class := c; class != nil; class = class.Superclass {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
+
+// Instance is an object created by calling a Class. Its fields are set through self.
+// Fields may be read and assigned concurrently by spawned functions.
+type Instance struct {
+	Class  *Class
+	mu     sync.RWMutex
+	fields map[string]Object
+	names  []string // field names in assignment order
+}
+
+// NewInstance creates an instance of class without any fields
+func NewInstance(class *Class) *Instance {
+	return &Instance{Class: class, fields: make(map[string]Object)}
+}
+
+// Type returns the type of the object
+func (i *Instance) Type() ObjectType { return INSTANCE_OBJ }
+
+// Inspect returns the string representation of the object
+func (i *Instance) Inspect() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var out bytes.Buffer
+
+	var fields []string
+	for _, name := range i.names {
+		fields = append(fields, name+": "+i.fields[name].Inspect())
+	}
+
+	out.WriteString(i.Class.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Get returns the field name of the instance
+func (i *Instance) Get(name string) (Object, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	value, ok := i.fields[name]
+	return value, ok
+}
+
+// Set assigns the field name of the instance
+func (i *Instance) Set(name string, value Object) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.fields[name]; !ok {
+		i.names = append(i.names, name)
+	}
+	i.fields[name] = value
+}
+
+// BoundMethod is a method looked up on an instance. Calling it runs Method with self bound to Receiver.
+type BoundMethod struct {
+	Name     string
+	Receiver *Instance
+	Method   *Function
+	Class    *Class // the class defining Method, where super lookups start from
+}
+
+// Type returns the type of the object
+func (bm *BoundMethod) Type() ObjectType { return BOUND_METHOD_OBJ }
+
+// Inspect returns the string representation of the object
+func (bm *BoundMethod) Inspect() string {
+	return bm.Class.Name + "." + bm.Name
+}
+
+// Super is the value of super inside a method. Its methods are looked up from Class, bound to Receiver.
+type Super struct {
+	Receiver *Instance
+	Class    *Class
+}
+
+// Type returns the type of the object
+func (s *Super) Type() ObjectType { return SUPER_OBJ }
+
+// Inspect returns the string representation of the object
+func (s *Super) Inspect() string {
+	return "super " + s.Class.Name
+}
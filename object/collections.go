@@ -0,0 +1,136 @@
+package object
+
+import (
+	"strings"
+)
+
+// Set is an unordered collection of distinct hashable values.
+// Elements are kept in insertion order so that Inspect and iteration are stable.
+type Set struct {
+	items *Hash
+}
+
+// NewSet creates a set holding elements, dropping duplicates
+func NewSet(elements ...Hashable) *Set {
+	s := &Set{items: NewHash()}
+	for _, el := range elements {
+		s.Add(el)
+	}
+	return s
+}
+
+// Add inserts element unless an equal one is already in the set
+func (s *Set) Add(element Hashable) {
+	if _, ok := s.items.Get(element); !ok {
+		s.items.Set(element, element)
+	}
+}
+
+// Contains reports whether an element equal to element is in the set
+func (s *Set) Contains(element Hashable) bool {
+	_, ok := s.items.Get(element)
+	return ok
+}
+
+// Len returns the number of elements in the set
+func (s *Set) Len() int { return s.items.Len() }
+
+// Elements returns the elements of the set in insertion order
+func (s *Set) Elements() []Object {
+	elements := make([]Object, 0, s.Len())
+	for _, pair := range s.items.Pairs() {
+		elements = append(elements, pair.Key)
+	}
+	return elements
+}
+
+// Union returns a new set with the elements of s followed by those of other
+func (s *Set) Union(other *Set) *Set {
+	result := NewSet()
+	for _, pair := range s.items.Pairs() {
+		result.Add(pair.Key.(Hashable))
+	}
+	for _, pair := range other.items.Pairs() {
+		result.Add(pair.Key.(Hashable))
+	}
+	return result
+}
+
+// Intersection returns a new set with the elements of s that are also in other
+func (s *Set) Intersection(other *Set) *Set {
+	result := NewSet()
+	for _, pair := range s.items.Pairs() {
+		if key := pair.Key.(Hashable); other.Contains(key) {
+			result.Add(key)
+		}
+	}
+	return result
+}
+
+// Difference returns a new set with the elements of s that are not in other
+func (s *Set) Difference(other *Set) *Set {
+	result := NewSet()
+	for _, pair := range s.items.Pairs() {
+		if key := pair.Key.(Hashable); !other.Contains(key) {
+			result.Add(key)
+		}
+	}
+	return result
+}
+
+// Type returns the type of the object
+func (s *Set) Type() ObjectType { return SET_OBJ }
+
+// Inspect returns the string representation of the object. The empty set is shown as set(),
+// since {} is an empty hash.
+func (s *Set) Inspect() string {
+	if s.Len() == 0 {
+		return "set()"
+	}
+
+	var elements []string
+	for _, el := range s.Elements() {
+		elements = append(elements, el.Inspect())
+	}
+
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// HashKey returns the hash key of the object.
+// The element keys are summed, so sets with the same elements hash alike whatever their order.
+func (s *Set) HashKey() HashKey {
+	var sum uint64
+	for _, pair := range s.items.Pairs() {
+		sum += hashElements(SET_OBJ, "", []Object{pair.Key}).Value
+	}
+	return HashKey{Type: s.Type(), Value: sum}
+}
+
+// Tuple is an immutable, fixed-size sequence of values
+type Tuple struct {
+	Elements []Object
+}
+
+// Type returns the type of the object
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+
+// Inspect returns the string representation of the object. A tuple of one element keeps its trailing comma.
+func (t *Tuple) Inspect() string {
+	var elements []string
+	for _, el := range t.Elements {
+		elements = append(elements, el.Inspect())
+	}
+
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// HashKey returns the hash key of the object.
+// Callers must check the elements with AsHashable before using a tuple as a key.
+func (t *Tuple) HashKey() HashKey {
+	return hashElements(t.Type(), "", t.Elements)
+}
+
+func (t *Tuple) elements() []Object { return t.Elements }
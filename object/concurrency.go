@@ -0,0 +1,118 @@
+package object
+
+import "sync"
+
+// Channel passes values between spawned functions
+type Channel struct {
+	ch     chan Object
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewChannel creates a channel buffering up to capacity values
+func NewChannel(capacity int) *Channel {
+	return &Channel{ch: make(chan Object, capacity)}
+}
+
+// Type returns the type of the object
+func (c *Channel) Type() ObjectType { return CHANNEL_OBJ }
+
+// Inspect returns the string representation of the object
+func (c *Channel) Inspect() string { return "channel" }
+
+// Send blocks until value is delivered and reports false if the channel is closed
+func (c *Channel) Send(value Object) (ok bool) {
+	// A close racing with a blocked send makes the send panic; report it as a closed channel.
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	c.ch <- value
+	return true
+}
+
+// Receive blocks until a value is available and reports false once the channel is closed and drained
+func (c *Channel) Receive() (Object, bool) {
+	value, ok := <-c.ch
+	return value, ok
+}
+
+// Close closes the channel and reports false if it was already closed
+func (c *Channel) Close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	close(c.ch)
+	return true
+}
+
+// Chan returns the underlying Go channel for receiving
+func (c *Channel) Chan() <-chan Object {
+	return c.ch
+}
+
+// Task is the handle of a spawned function. Its result is available once the function returns.
+type Task struct {
+	done   chan struct{}
+	result Object
+}
+
+// NewTask creates a task that has not finished yet
+func NewTask() *Task {
+	return &Task{done: make(chan struct{})}
+}
+
+// Type returns the type of the object
+func (t *Task) Type() ObjectType { return TASK_OBJ }
+
+// Inspect returns the string representation of the object
+func (t *Task) Inspect() string { return "task" }
+
+// Finish records the result of the task and wakes up everyone waiting for it. It must be called once.
+func (t *Task) Finish(result Object) {
+	t.result = result
+	close(t.done)
+}
+
+// Wait blocks until the task has finished and returns its result
+func (t *Task) Wait() Object {
+	<-t.done
+	return t.result
+}
+
+// WaitGroup waits for a number of spawned functions to report that they are done
+type WaitGroup struct {
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	count int64
+}
+
+// Type returns the type of the object
+func (w *WaitGroup) Type() ObjectType { return WAITGROUP_OBJ }
+
+// Inspect returns the string representation of the object
+func (w *WaitGroup) Inspect() string { return "waitgroup" }
+
+// Add adds delta to the counter and reports false, leaving the counter unchanged, if it would become negative
+func (w *WaitGroup) Add(delta int64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.count+delta < 0 {
+		return false
+	}
+	w.count += delta
+	w.wg.Add(int(delta))
+	return true
+}
+
+// Wait blocks until the counter is zero
+func (w *WaitGroup) Wait() {
+	w.wg.Wait()
+}
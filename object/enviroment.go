@@ -1,14 +1,47 @@
 package object
 
+import (
+	"fmt"
+	"sync"
+)
+
+// Importer loads modules for the import statements evaluated in an environment
+type Importer interface {
+	// Import returns the namespace of the module at path, or an *Error
+	Import(path string) Object
+}
+
+// Yielder receives the values yielded by the generator function running in an environment
+type Yielder interface {
+	// Yield hands value to the consumer of the generator and returns once the next value is requested
+	Yield(value Object)
+}
+
+// Awaiter waits for futures on behalf of the code evaluated in an environment: the event loop
+// of a program, or the async function whose body runs there
+type Awaiter interface {
+	// Await returns the value of future once it is resolved, letting other async work run meanwhile
+	Await(future *Future) Object
+}
+
+// Environment holds the bindings of a scope. It is safe for concurrent use,
+// since functions started with spawn share the environments they close over.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	mu        sync.RWMutex
+	store     map[string]Object
+	constants map[string]bool
+	outer     *Environment
+	exports   []string
+	importer  Importer
+	yielder   Yielder
+	awaiter   Awaiter
 }
 
 // NewEnvironment creates a new environment
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s}
+	c := make(map[string]bool)
+	return &Environment{store: s, constants: c}
 }
 
 // NewEnclosedEnvironment creates a new environment with the given outer environment
@@ -20,15 +53,123 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 
 // Get returns the object associated with the given key
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
 	return obj, ok
 }
 
-// Set sets the value of the given key to the given object
-func (e *Environment) Set(name string, val Object) Object {
+// Set sets the value of the given key to the given object.
+// It fails when the key is a constant of this environment; enclosed environments may still shadow it.
+func (e *Environment) Set(name string, val Object) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.constants[name] {
+		return fmt.Errorf("cannot reassign constant %s", name)
+	}
 	e.store[name] = val
-	return val
+	return nil
+}
+
+// SetConst sets the value of the given key to the given object and makes the key a constant
+func (e *Environment) SetConst(name string, val Object) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.constants[name] {
+		return fmt.Errorf("cannot reassign constant %s", name)
+	}
+	e.store[name] = val
+	e.constants[name] = true
+	return nil
+}
+
+// IsConst reports whether the given key is a constant of this environment
+func (e *Environment) IsConst(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.constants[name]
+}
+
+// Lock makes every key currently set in this environment a constant,
+// e.g. to protect the global environment once a prelude has been loaded
+func (e *Environment) Lock() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for name := range e.store {
+		e.constants[name] = true
+	}
+}
+
+// Export marks the given name as exported from this environment
+func (e *Environment) Export(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, exported := range e.exports {
+		if exported == name {
+			return
+		}
+	}
+	e.exports = append(e.exports, name)
+}
+
+// Exports returns the exported names in the order they were exported
+func (e *Environment) Exports() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return append([]string{}, e.exports...)
+}
+
+// SetImporter sets the importer used by this environment and the environments enclosed by it
+func (e *Environment) SetImporter(importer Importer) {
+	e.importer = importer
+}
+
+// Importer returns the importer of the nearest environment that has one
+func (e *Environment) Importer() Importer {
+	if e.importer == nil && e.outer != nil {
+		return e.outer.Importer()
+	}
+	return e.importer
+}
+
+// SetYielder sets the yielder of the generator function whose body runs in this environment
+func (e *Environment) SetYielder(yielder Yielder) {
+	e.yielder = yielder
+}
+
+// Yielder returns the yielder of the nearest environment that has one
+func (e *Environment) Yielder() Yielder {
+	if e.yielder == nil && e.outer != nil {
+		return e.outer.Yielder()
+	}
+	return e.yielder
+}
+
+// SetAwaiter sets the awaiter of the code evaluated in this environment
+func (e *Environment) SetAwaiter(awaiter Awaiter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.awaiter = awaiter
+}
+
+// Awaiter returns the awaiter of the nearest environment that has one
+func (e *Environment) Awaiter() Awaiter {
+	e.mu.RLock()
+	awaiter := e.awaiter
+	e.mu.RUnlock()
+
+	if awaiter == nil && e.outer != nil {
+		return e.outer.Awaiter()
+	}
+	return awaiter
 }
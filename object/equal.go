@@ -0,0 +1,106 @@
+package object
+
+// Equal reports whether two objects hold the same value.
+// Arrays, tuples, sets, hashes, structs and enum variants are compared structurally; sets and hashes ignore insertion order
+// and structs and variants must share their declaration. Ranges are equal when they hold the same integers, so all empty ranges are equal.
+// Functions and builtins are only equal to themselves.
+func Equal(a, b Object) bool {
+	if a == b {
+		return true
+	}
+
+	if a == nil || b == nil || a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		b, ok := b.(*Integer)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !Equal(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !Equal(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Range:
+		b, ok := b.(*Range)
+		return ok && a.Len() == b.Len() && (a.Len() == 0 || a.Start == b.Start)
+	case *Set:
+		b, ok := b.(*Set)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, el := range a.Elements() {
+			if !b.Contains(el.(Hashable)) {
+				return false
+			}
+		}
+		return true
+	case *Struct:
+		b, ok := b.(*Struct)
+		if !ok || a.Definition != b.Definition {
+			return false
+		}
+		for i := range a.Values {
+			if !Equal(a.Values[i], b.Values[i]) {
+				return false
+			}
+		}
+		return true
+	case *Variant:
+		b, ok := b.(*Variant)
+		if !ok || a.Definition != b.Definition {
+			return false
+		}
+		for i := range a.Values {
+			if !Equal(a.Values[i], b.Values[i]) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.Pairs() {
+			key, ok := pair.Key.(Hashable)
+			if !ok {
+				return false
+			}
+			value, ok := b.Get(key)
+			if !ok || !Equal(pair.Value, value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
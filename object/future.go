@@ -0,0 +1,100 @@
+package object
+
+import "sync"
+
+// Future is a value that becomes available later, such as the result of an async function
+// or of a host builtin doing slow I/O. It may be resolved from any goroutine; resolving it
+// with an *Error rejects it, and awaiting it then yields that error.
+//
+// A host builtin returns a future and resolves it once its work is done:
+//
+//	Fn: func(args ...Object) Object {
+//		return FutureOf(func() Object { return fetch(args[0]) })
+//	}
+type Future struct {
+	mu        sync.Mutex
+	done      bool
+	value     Object
+	callbacks []func()
+}
+
+// NewFuture creates a pending future
+func NewFuture() *Future {
+	return &Future{}
+}
+
+// FutureOf runs fn on a new goroutine and returns a future resolved with its result
+func FutureOf(fn func() Object) *Future {
+	future := NewFuture()
+	go func() { future.Resolve(fn()) }()
+	return future
+}
+
+// Type returns the type of the object
+func (f *Future) Type() ObjectType { return FUTURE_OBJ }
+
+// Inspect returns the string representation of the object
+func (f *Future) Inspect() string {
+	if value, ok := f.Result(); ok {
+		return "future(" + value.Inspect() + ")"
+	}
+	return "future(pending)"
+}
+
+// Resolve sets the value of the future and runs the callbacks waiting for it.
+// It reports false, leaving the future unchanged, if it was already resolved.
+func (f *Future) Resolve(value Object) bool {
+	f.mu.Lock()
+	if f.done {
+		f.mu.Unlock()
+		return false
+	}
+	f.done = true
+	f.value = value
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+	return true
+}
+
+// Reject resolves the future with an error
+func (f *Future) Reject(message string) bool {
+	return f.Resolve(&Error{Message: message})
+}
+
+// Result returns the value of the future and reports whether it is resolved yet
+func (f *Future) Result() (Object, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.value, f.done
+}
+
+// OnResolve calls callback once the future is resolved: right away if it already is,
+// otherwise on the goroutine that resolves it
+func (f *Future) OnResolve(callback func()) {
+	f.mu.Lock()
+	if !f.done {
+		f.callbacks = append(f.callbacks, callback)
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+
+	callback()
+}
+
+// Wait blocks until the future is resolved and returns its value.
+// It is meant for host code; Monkey code awaits futures so that the event loop keeps running.
+func (f *Future) Wait() Object {
+	done := make(chan struct{})
+	f.OnResolve(func() { close(done) })
+	<-done
+
+	value, _ := f.Result()
+	return value
+}
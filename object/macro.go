@@ -0,0 +1,37 @@
+package object
+
+import (
+	"gtihub.com/yudai2929/monkey-lang/ast"
+	"strings"
+)
+
+// Quote is an unevaluated AST node, produced by quote(...) and passed to macros
+type Quote struct {
+	Node ast.Node
+}
+
+// Type returns the type of the object
+func (q *Quote) Type() ObjectType { return QUOTE_OBJ }
+
+// Inspect returns the string representation of the object
+func (q *Quote) Inspect() string { return "QUOTE(" + q.Node.String() + ")" }
+
+// Macro is a macro defined with let name = macro(...) { ... }. It only exists during macro expansion.
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+// Type returns the type of the object
+func (m *Macro) Type() ObjectType { return MACRO_OBJ }
+
+// Inspect returns the string representation of the object
+func (m *Macro) Inspect() string {
+	var params []string
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
+	}
+
+	return "macro(" + strings.Join(params, ", ") + ") {\n" + m.Body.String() + "\n}"
+}
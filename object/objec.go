@@ -2,9 +2,11 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"gtihub.com/yudai2929/monkey-lang/ast"
 	"hash/fnv"
+	"strings"
 )
 
 // ObjectType is the type of the object
@@ -31,6 +33,44 @@ const (
 	ARRAY_OBJ = "ARRAY"
 	// HASH_OBJ is the hash object type
 	HASH_OBJ = "HASH"
+	// STRUCT_TYPE_OBJ is the struct declaration object type
+	STRUCT_TYPE_OBJ = "STRUCT_TYPE"
+	// STRUCT_OBJ is the struct instance object type
+	STRUCT_OBJ = "STRUCT"
+	// ENUM_OBJ is the enum declaration object type
+	ENUM_OBJ = "ENUM"
+	// VARIANT_TYPE_OBJ is the enum variant constructor object type
+	VARIANT_TYPE_OBJ = "VARIANT_TYPE"
+	// VARIANT_OBJ is the enum variant value object type
+	VARIANT_OBJ = "VARIANT"
+	// CLASS_OBJ is the class declaration object type
+	CLASS_OBJ = "CLASS"
+	// INSTANCE_OBJ is the class instance object type
+	INSTANCE_OBJ = "INSTANCE"
+	// BOUND_METHOD_OBJ is the object type of a method bound to its receiver
+	BOUND_METHOD_OBJ = "BOUND_METHOD"
+	// SUPER_OBJ is the object type of super inside a method
+	SUPER_OBJ = "SUPER"
+	// GENERATOR_OBJ is the generator object type
+	GENERATOR_OBJ = "GENERATOR"
+	// CHANNEL_OBJ is the channel object type
+	CHANNEL_OBJ = "CHANNEL"
+	// TASK_OBJ is the object type of a spawned function
+	TASK_OBJ = "TASK"
+	// WAITGROUP_OBJ is the wait group object type
+	WAITGROUP_OBJ = "WAITGROUP"
+	// FUTURE_OBJ is the future object type
+	FUTURE_OBJ = "FUTURE"
+	// SET_OBJ is the set object type
+	SET_OBJ = "SET"
+	// TUPLE_OBJ is the tuple object type
+	TUPLE_OBJ = "TUPLE"
+	// RANGE_OBJ is the integer range object type
+	RANGE_OBJ = "RANGE"
+	// QUOTE_OBJ is the object type of a quoted AST node
+	QUOTE_OBJ = "QUOTE"
+	// MACRO_OBJ is the macro object type
+	MACRO_OBJ = "MACRO"
 )
 
 // HashKey is the hash key object
@@ -41,6 +81,7 @@ type HashKey struct {
 
 // Hashable is the interface that all hashable objects in the interpreter implement
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
 
@@ -122,9 +163,11 @@ func (e *Error) Inspect() string { return "ERROR: " + e.Message }
 
 // Function is the function object
 type Function struct {
-	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
+	Parameters  []*ast.Identifier
+	Body        *ast.BlockStatement
+	Env         *Environment
+	IsGenerator bool // calling the function returns a Generator running Body
+	IsAsync     bool // calling the function returns a Future resolved with the result of Body
 }
 
 // Type returns the type of the object
@@ -206,15 +249,117 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+// HashKey returns the hash key of the object.
+// Callers must check the elements with AsHashable before using an array as a key.
+func (ao *Array) HashKey() HashKey {
+	return hashElements(ao.Type(), "", ao.Elements)
+}
+
+func (ao *Array) elements() []Object { return ao.Elements }
+
+// container is implemented by objects that are only usable as hash keys when all their elements are
+type container interface {
+	Hashable
+	elements() []Object
+}
+
+// hashElements combines the hash keys of elements, prefixed by tag, into a hash key of type t
+func hashElements(t ObjectType, tag string, elements []Object) HashKey {
+	var h = fnv.New64a()
+	var buf [8]byte
+
+	h.Write([]byte(tag))
+	for _, el := range elements {
+		if hashable, ok := el.(Hashable); ok {
+			key := hashable.HashKey()
+			h.Write([]byte(key.Type))
+			binary.LittleEndian.PutUint64(buf[:], key.Value)
+			h.Write(buf[:])
+		}
+	}
+
+	return HashKey{Type: t, Value: h.Sum64()}
+}
+
 // HashPair is the key-value pair of the hash object
 type HashPair struct {
 	Key   Object
 	Value Object
 }
 
-// Hash is the hash object
+// Hash is the hash object. Pairs are kept in insertion order.
+//
+// HashKey only selects a bucket; keys within a bucket are compared by value,
+// so two keys whose hashes collide never overwrite each other.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	buckets map[HashKey][]int
+	entries []HashPair
+}
+
+// NewHash creates an empty hash
+func NewHash() *Hash {
+	return &Hash{buckets: make(map[HashKey][]int)}
+}
+
+// lookup returns the index of key in entries, or -1 if it is not stored
+func (h *Hash) lookup(key Hashable) int {
+	for _, idx := range h.buckets[key.HashKey()] {
+		if Equal(h.entries[idx].Key, key) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Set stores value under key. Overwriting an existing key keeps its original position.
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.buckets == nil {
+		h.buckets = make(map[HashKey][]int)
+	}
+
+	if idx := h.lookup(key); idx >= 0 {
+		h.entries[idx].Value = value
+		return
+	}
+
+	hashed := key.HashKey()
+	h.buckets[hashed] = append(h.buckets[hashed], len(h.entries))
+	h.entries = append(h.entries, HashPair{Key: key, Value: value})
+}
+
+// Get returns the value stored under key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	idx := h.lookup(key)
+	if idx < 0 {
+		return nil, false
+	}
+	return h.entries[idx].Value, true
+}
+
+// Len returns the number of pairs in the hash
+func (h *Hash) Len() int { return len(h.entries) }
+
+// Pairs returns the pairs of the hash in insertion order
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, len(h.entries))
+	copy(pairs, h.entries)
+	return pairs
+}
+
+// AsHashable returns obj as a Hashable if it can be used as a hash key.
+// Arrays, tuples and structs are only usable when every element is itself usable.
+func AsHashable(obj Object) (Hashable, bool) {
+	if c, ok := obj.(container); ok {
+		for _, el := range c.elements() {
+			if _, ok := AsHashable(el); !ok {
+				return nil, false
+			}
+		}
+		return c, true
+	}
+
+	hashable, ok := obj.(Hashable)
+	return hashable, ok
 }
 
 // Type returns the type of the object
@@ -225,7 +370,7 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	var pairs []string
-	for _, pair := range h.Pairs {
+	for _, pair := range h.Pairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -235,3 +380,193 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+// StructType is a struct declaration. Calling it constructs a Struct.
+type StructType struct {
+	Name   string
+	Fields []string
+}
+
+// Type returns the type of the object
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+
+// Inspect returns the string representation of the object
+func (st *StructType) Inspect() string {
+	return "struct " + st.Name + " { " + strings.Join(st.Fields, ", ") + " }"
+}
+
+// FieldIndex returns the position of the field name, or -1 if the struct has no such field
+func (st *StructType) FieldIndex(name string) int {
+	for i, field := range st.Fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Struct is an instance of a StructType. Values holds the fields in declaration order.
+type Struct struct {
+	Definition *StructType
+	Values     []Object
+}
+
+// Type returns the type of the object
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+
+// Inspect returns the string representation of the object
+func (s *Struct) Inspect() string {
+	var out bytes.Buffer
+
+	var fields []string
+	for i, field := range s.Definition.Fields {
+		fields = append(fields, field+": "+s.Values[i].Inspect())
+	}
+
+	out.WriteString(s.Definition.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Field returns the value of the field name
+func (s *Struct) Field(name string) (Object, bool) {
+	idx := s.Definition.FieldIndex(name)
+	if idx < 0 {
+		return nil, false
+	}
+	return s.Values[idx], true
+}
+
+// HashKey returns the hash key of the object.
+// Callers must check the fields with AsHashable before using a struct as a key.
+func (s *Struct) HashKey() HashKey {
+	return hashElements(s.Type(), s.Definition.Name, s.Values)
+}
+
+func (s *Struct) elements() []Object { return s.Values }
+
+// EnumType is an enum declaration. Its variants are reachable as Name.Variant.
+type EnumType struct {
+	Name     string
+	Variants []*VariantType
+}
+
+// Type returns the type of the object
+func (et *EnumType) Type() ObjectType { return ENUM_OBJ }
+
+// Inspect returns the string representation of the object
+func (et *EnumType) Inspect() string {
+	var variants []string
+	for _, v := range et.Variants {
+		variants = append(variants, v.signature())
+	}
+	return "enum " + et.Name + " { " + strings.Join(variants, ", ") + " }"
+}
+
+// Variant returns the variant declared as name
+func (et *EnumType) Variant(name string) (*VariantType, bool) {
+	for _, v := range et.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// VariantType is one variant of an enum. Calling it constructs a Variant carrying Fields.
+// Variants without fields are values on their own; Unit holds that single instance.
+type VariantType struct {
+	Enum   *EnumType
+	Name   string
+	Fields []string
+	Unit   *Variant
+}
+
+// Type returns the type of the object
+func (vt *VariantType) Type() ObjectType { return VARIANT_TYPE_OBJ }
+
+// Inspect returns the string representation of the object
+func (vt *VariantType) Inspect() string {
+	return vt.Enum.Name + "." + vt.signature()
+}
+
+func (vt *VariantType) signature() string {
+	if len(vt.Fields) == 0 {
+		return vt.Name
+	}
+	return vt.Name + "(" + strings.Join(vt.Fields, ", ") + ")"
+}
+
+// FieldIndex returns the position of the field name, or -1 if the variant has no such field
+func (vt *VariantType) FieldIndex(name string) int {
+	for i, field := range vt.Fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Variant is a value of an enum, tagged with the variant that built it
+type Variant struct {
+	Definition *VariantType
+	Values     []Object
+}
+
+// Type returns the type of the object
+func (v *Variant) Type() ObjectType { return VARIANT_OBJ }
+
+// Inspect returns the string representation of the object
+func (v *Variant) Inspect() string {
+	if len(v.Values) == 0 {
+		return v.Definition.Name
+	}
+
+	var values []string
+	for _, value := range v.Values {
+		values = append(values, value.Inspect())
+	}
+
+	return v.Definition.Name + "(" + strings.Join(values, ", ") + ")"
+}
+
+// Field returns the value of the field name
+func (v *Variant) Field(name string) (Object, bool) {
+	idx := v.Definition.FieldIndex(name)
+	if idx < 0 {
+		return nil, false
+	}
+	return v.Values[idx], true
+}
+
+// HashKey returns the hash key of the object.
+// Callers must check the fields with AsHashable before using a variant as a key.
+func (v *Variant) HashKey() HashKey {
+	return hashElements(v.Type(), v.Definition.Enum.Name+"."+v.Definition.Name, v.Values)
+}
+
+func (v *Variant) elements() []Object { return v.Values }
+
+// Generator is a lazily produced sequence of values, such as the result of calling a function that yields.
+// Next returns the following value and reports false once the sequence is exhausted.
+// Stop, which may be nil, ends the sequence early and releases whatever produces it.
+type Generator struct {
+	Next func() (Object, bool)
+	Stop func()
+}
+
+// Close stops a generator that is not exhausted yet; Next then reports false
+func (g *Generator) Close() {
+	if g.Stop != nil {
+		g.Stop()
+	}
+}
+
+// Type returns the type of the object
+func (g *Generator) Type() ObjectType { return GENERATOR_OBJ }
+
+// Inspect returns the string representation of the object
+func (g *Generator) Inspect() string { return "generator" }
@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -20,3 +25,433 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestHashPairsKeepInsertionOrder(t *testing.T) {
+	h := NewHash()
+	h.Set(&String{Value: "b"}, &Integer{Value: 1})
+	h.Set(&String{Value: "a"}, &Integer{Value: 2})
+	h.Set(&String{Value: "b"}, &Integer{Value: 3})
+
+	pairs := h.Pairs()
+	if len(pairs) != 2 {
+		t.Fatalf("hash has wrong num of pairs. got=%d", len(pairs))
+	}
+
+	if pairs[0].Key.Inspect() != "b" || pairs[1].Key.Inspect() != "a" {
+		t.Errorf("pairs out of insertion order. got=%s", h.Inspect())
+	}
+
+	if pairs[0].Value.Inspect() != "3" {
+		t.Errorf("overwritten value not stored. got=%s", pairs[0].Value.Inspect())
+	}
+}
+
+// collidingKey always hashes to the same bucket
+type collidingKey struct {
+	name string
+}
+
+func (c *collidingKey) Type() ObjectType { return "COLLIDING" }
+func (c *collidingKey) Inspect() string  { return c.name }
+func (c *collidingKey) HashKey() HashKey { return HashKey{Type: c.Type(), Value: 42} }
+
+func TestHashKeepsCollidingKeysApart(t *testing.T) {
+	a := &collidingKey{name: "a"}
+	b := &collidingKey{name: "b"}
+
+	h := NewHash()
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other. got=%s", h.Inspect())
+	}
+
+	if value, ok := h.Get(a); !ok || value.Inspect() != "1" {
+		t.Errorf("wrong value for key a. got=%v", value)
+	}
+
+	if value, ok := h.Get(b); !ok || value.Inspect() != "2" {
+		t.Errorf("wrong value for key b. got=%v", value)
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	arr1 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	arr2 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	diff := &Array{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+
+	if arr1.HashKey() != arr2.HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+
+	if arr1.HashKey() == diff.HashKey() {
+		t.Errorf("arrays with different content have same hash keys")
+	}
+
+	if _, ok := AsHashable(&Array{Elements: []Object{&Function{}}}); ok {
+		t.Errorf("array containing a function is usable as hash key")
+	}
+}
+
+func TestEnvironmentConstants(t *testing.T) {
+	env := NewEnvironment()
+
+	if err := env.SetConst("x", &Integer{Value: 1}); err != nil {
+		t.Fatalf("SetConst failed: %s", err)
+	}
+
+	if err := env.Set("x", &Integer{Value: 2}); err == nil || err.Error() != "cannot reassign constant x" {
+		t.Errorf("rebinding a constant did not fail. got=%v", err)
+	}
+
+	if value, _ := env.Get("x"); value.Inspect() != "1" {
+		t.Errorf("constant was overwritten. got=%s", value.Inspect())
+	}
+
+	inner := NewEnclosedEnvironment(env)
+	if err := inner.Set("x", &Integer{Value: 3}); err != nil {
+		t.Errorf("shadowing a constant in an enclosed environment failed: %s", err)
+	}
+}
+
+func TestEnvironmentLock(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("prelude", &Integer{Value: 1})
+	env.Lock()
+
+	if err := env.Set("prelude", &Integer{Value: 2}); err == nil {
+		t.Errorf("rebinding a locked binding did not fail")
+	}
+
+	if err := env.Set("user", &Integer{Value: 3}); err != nil {
+		t.Errorf("new binding after Lock failed: %s", err)
+	}
+
+	if !env.IsConst("prelude") || env.IsConst("user") {
+		t.Errorf("wrong constants after Lock")
+	}
+}
+
+func TestStructHashKey(t *testing.T) {
+	point := &StructType{Name: "Point", Fields: []string{"x", "y"}}
+	vector := &StructType{Name: "Vector", Fields: []string{"x", "y"}}
+
+	p1 := &Struct{Definition: point, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+	p2 := &Struct{Definition: point, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+	v := &Struct{Definition: vector, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+
+	if p1.HashKey() != p2.HashKey() {
+		t.Errorf("structs with same content have different hash keys")
+	}
+
+	if p1.HashKey() == v.HashKey() {
+		t.Errorf("structs of different types have same hash keys")
+	}
+
+	if !Equal(p1, p2) || Equal(p1, v) {
+		t.Errorf("struct equality is wrong")
+	}
+
+	if p1.Inspect() != "Point{x: 1, y: 2}" {
+		t.Errorf("wrong Inspect. got=%q", p1.Inspect())
+	}
+}
+
+func TestVariantHashKey(t *testing.T) {
+	result := &EnumType{Name: "Result"}
+	ok := &VariantType{Enum: result, Name: "Ok", Fields: []string{"value"}}
+	err := &VariantType{Enum: result, Name: "Err", Fields: []string{"msg"}}
+	result.Variants = []*VariantType{ok, err}
+
+	ok1 := &Variant{Definition: ok, Values: []Object{&Integer{Value: 1}}}
+	ok2 := &Variant{Definition: ok, Values: []Object{&Integer{Value: 1}}}
+	err1 := &Variant{Definition: err, Values: []Object{&Integer{Value: 1}}}
+
+	if ok1.HashKey() != ok2.HashKey() {
+		t.Errorf("variants with same content have different hash keys")
+	}
+
+	if ok1.HashKey() == err1.HashKey() {
+		t.Errorf("different variants have same hash keys")
+	}
+
+	if !Equal(ok1, ok2) || Equal(ok1, err1) {
+		t.Errorf("variant equality is wrong")
+	}
+
+	if ok1.Inspect() != "Ok(1)" {
+		t.Errorf("wrong Inspect. got=%q", ok1.Inspect())
+	}
+
+	if result.Inspect() != "enum Result { Ok(value), Err(msg) }" {
+		t.Errorf("wrong Inspect. got=%q", result.Inspect())
+	}
+}
+
+func TestClassMethodLookup(t *testing.T) {
+	speak := &Function{}
+	init := &Function{}
+	animal := &Class{Name: "Animal", Methods: map[string]*Function{"speak": speak, "init": init}}
+	dog := &Class{Name: "Dog", Superclass: animal, Methods: map[string]*Function{"init": &Function{}}}
+
+	method, class, ok := dog.Method("speak")
+	if !ok || method != speak || class != animal {
+		t.Errorf("speak not inherited from Animal. got=%v, %v, %v", method, class, ok)
+	}
+
+	if method, _, _ := dog.Method("init"); method == init {
+		t.Errorf("init of Dog does not override Animal")
+	}
+
+	if _, _, ok := dog.Method("fly"); ok {
+		t.Errorf("undefined method found")
+	}
+
+	if !dog.IsSubclassOf(animal) || animal.IsSubclassOf(dog) {
+		t.Errorf("wrong subclass relation")
+	}
+
+	instance := NewInstance(dog)
+	instance.Set("name", &String{Value: "Rex"})
+	instance.Set("age", &Integer{Value: 3})
+	instance.Set("name", &String{Value: "Max"})
+
+	if instance.Inspect() != "Dog{name: Max, age: 3}" {
+		t.Errorf("wrong Inspect. got=%q", instance.Inspect())
+	}
+}
+
+func TestEnvironmentConcurrentAccess(t *testing.T) {
+	env := NewEnvironment()
+	inner := NewEnclosedEnvironment(env)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			env.Set(fmt.Sprintf("x%d", i), &Integer{Value: int64(i)})
+			inner.Get(fmt.Sprintf("x%d", i))
+			env.Export(fmt.Sprintf("x%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if len(env.Exports()) != 50 {
+		t.Errorf("wrong number of exports. got=%d", len(env.Exports()))
+	}
+
+	if value, ok := inner.Get("x49"); !ok || value.Inspect() != "49" {
+		t.Errorf("wrong value for x49. got=%v", value)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	ch := NewChannel(1)
+
+	if !ch.Send(&Integer{Value: 1}) {
+		t.Fatalf("send on open channel failed")
+	}
+
+	if value, ok := ch.Receive(); !ok || value.Inspect() != "1" {
+		t.Errorf("wrong received value. got=%v, %v", value, ok)
+	}
+
+	if !ch.Close() || ch.Close() {
+		t.Errorf("channel must close exactly once")
+	}
+
+	if ch.Send(&Integer{Value: 2}) {
+		t.Errorf("send on closed channel succeeded")
+	}
+
+	if _, ok := ch.Receive(); ok {
+		t.Errorf("receive on closed channel succeeded")
+	}
+}
+
+func TestWaitGroupRejectsNegativeCounter(t *testing.T) {
+	wg := &WaitGroup{}
+
+	if wg.Add(-1) {
+		t.Errorf("counter went negative")
+	}
+
+	if !wg.Add(2) || !wg.Add(-2) {
+		t.Errorf("balanced Add failed")
+	}
+
+	wg.Wait()
+}
+
+func TestFuture(t *testing.T) {
+	future := NewFuture()
+
+	if _, ok := future.Result(); ok {
+		t.Fatalf("new future is resolved")
+	}
+
+	called := make(chan struct{})
+	future.OnResolve(func() { close(called) })
+
+	if !future.Resolve(&Integer{Value: 1}) {
+		t.Fatalf("resolving a pending future failed")
+	}
+	<-called
+
+	if future.Resolve(&Integer{Value: 2}) || future.Reject("late") {
+		t.Errorf("resolving a resolved future succeeded")
+	}
+
+	if value, ok := future.Result(); !ok || value.Inspect() != "1" {
+		t.Errorf("wrong result. got=%v, %v", value, ok)
+	}
+
+	ran := false
+	future.OnResolve(func() { ran = true })
+	if !ran {
+		t.Errorf("callback on resolved future did not run right away")
+	}
+
+	if future.Inspect() != "future(1)" {
+		t.Errorf("wrong Inspect. got=%q", future.Inspect())
+	}
+
+	result := FutureOf(func() Object { return &String{Value: "done"} }).Wait()
+	if result.Inspect() != "done" {
+		t.Errorf("wrong FutureOf result. got=%q", result.Inspect())
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	one, two, three := &Integer{Value: 1}, &Integer{Value: 2}, &Integer{Value: 3}
+
+	a := NewSet(one, two, &Integer{Value: 2})
+	b := NewSet(three, two)
+
+	if a.Len() != 2 {
+		t.Fatalf("duplicate element stored. got=%s", a.Inspect())
+	}
+
+	tests := []struct {
+		set      *Set
+		expected string
+	}{
+		{a.Union(b), "{1, 2, 3}"},
+		{a.Intersection(b), "{2}"},
+		{a.Difference(b), "{1}"},
+		{b.Difference(b), "set()"},
+	}
+
+	for _, tt := range tests {
+		if tt.set.Inspect() != tt.expected {
+			t.Errorf("wrong set. expected=%q, got=%q", tt.expected, tt.set.Inspect())
+		}
+	}
+
+	if !a.Contains(&Integer{Value: 1}) || a.Contains(three) {
+		t.Errorf("wrong membership for %s", a.Inspect())
+	}
+
+	if NewSet(one, two).HashKey() != NewSet(two, one).HashKey() || !Equal(NewSet(one, two), NewSet(two, one)) {
+		t.Errorf("sets with the same elements in different order differ")
+	}
+
+	if Equal(a, b) {
+		t.Errorf("sets with different elements are equal")
+	}
+}
+
+func TestTupleHashKey(t *testing.T) {
+	t1 := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	t2 := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	arr := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+
+	if t1.HashKey() != t2.HashKey() || !Equal(t1, t2) {
+		t.Errorf("tuples with same content differ")
+	}
+
+	if t1.HashKey() == arr.HashKey() || Equal(t1, arr) {
+		t.Errorf("tuple and array with same content are alike")
+	}
+
+	if _, ok := AsHashable(&Tuple{Elements: []Object{&Function{}}}); ok {
+		t.Errorf("tuple containing a function is usable as hash key")
+	}
+
+	if t1.Inspect() != "(1, a)" {
+		t.Errorf("wrong Inspect. got=%q", t1.Inspect())
+	}
+
+	if single := (&Tuple{Elements: []Object{&Integer{Value: 1}}}); single.Inspect() != "(1,)" {
+		t.Errorf("wrong Inspect. got=%q", single.Inspect())
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		rng      *Range
+		length   uint64
+		inspect  string
+		contains []int64
+	}{
+		{&Range{Start: 0, End: 3}, 3, "0..3", []int64{0, 2}},
+		{&Range{Start: 0, End: 3, Inclusive: true}, 4, "0..=3", []int64{0, 3}},
+		{&Range{Start: 3, End: 0}, 0, "3..0", nil},
+		{&Range{Start: 3, End: 3}, 0, "3..3", nil},
+		{&Range{Start: -2, End: 2}, 4, "-2..2", []int64{-2, 1}},
+		{&Range{Start: -math.MaxInt64, End: math.MaxInt64}, math.MaxUint64 - 1, "-9223372036854775807..9223372036854775807", []int64{-math.MaxInt64, 0, math.MaxInt64 - 1}},
+		{&Range{Start: 0, End: math.MaxInt64, Inclusive: true}, 1 << 63, "0..=9223372036854775807", []int64{5, math.MaxInt64}},
+	}
+
+	for _, tt := range tests {
+		if tt.rng.Len() != tt.length {
+			t.Errorf("wrong Len for %s. got=%d, want=%d", tt.inspect, tt.rng.Len(), tt.length)
+		}
+
+		if tt.rng.Inspect() != tt.inspect {
+			t.Errorf("wrong Inspect. got=%q, want=%q", tt.rng.Inspect(), tt.inspect)
+		}
+
+		for _, n := range tt.contains {
+			if !tt.rng.Contains(n) {
+				t.Errorf("%s does not contain %d", tt.inspect, n)
+			}
+		}
+
+		if tt.rng.Contains(tt.rng.At(tt.length)) {
+			t.Errorf("%s contains the integer after its end", tt.inspect)
+		}
+	}
+
+	exclusive := &Range{Start: 0, End: 3}
+	inclusive := &Range{Start: 0, End: 2, Inclusive: true}
+	if exclusive.HashKey() != inclusive.HashKey() || !Equal(exclusive, inclusive) {
+		t.Errorf("ranges spanning the same integers differ")
+	}
+
+	if Equal(exclusive, &Range{Start: 1, End: 3}) {
+		t.Errorf("ranges with different starts are equal")
+	}
+
+	emptyRanges := []*Range{{Start: 0, End: 0}, {Start: 5, End: 5}, {Start: 5, End: 3, Inclusive: true}, {Start: 9, End: -9}}
+	for _, empty := range emptyRanges[1:] {
+		if !Equal(emptyRanges[0], empty) || emptyRanges[0].HashKey() != empty.HashKey() {
+			t.Errorf("empty ranges %s and %s differ", emptyRanges[0].Inspect(), empty.Inspect())
+		}
+	}
+
+	if Equal(emptyRanges[0], &Range{Start: 0, End: 0, Inclusive: true}) {
+		t.Errorf("an empty range equals 0..=0")
+	}
+
+	full := &Range{Start: math.MinInt64, End: math.MaxInt64, Inclusive: true}
+	if full.Len() != math.MaxUint64 || !full.Contains(math.MinInt64) || !full.Contains(math.MaxInt64) {
+		t.Errorf("wrong Len or Contains for %s. got Len=%d", full.Inspect(), full.Len())
+	}
+
+	if Equal(&Range{Start: 0, End: math.MaxInt64, Inclusive: true}, &Range{Start: 0, End: math.MinInt64}) {
+		t.Errorf("an inclusive range ending at math.MaxInt64 equals an empty range")
+	}
+}
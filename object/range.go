@@ -0,0 +1,69 @@
+package object
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// Range is the integer range start..end, or start..=end when Inclusive.
+// Its elements are computed on demand, so a range never allocates the integers it spans.
+// A range whose end lies before its start is empty.
+type Range struct {
+	Start     int64
+	End       int64
+	Inclusive bool
+}
+
+// Len returns the number of integers in the range. It is computed in uint64, which holds the
+// length of every range but math.MinInt64..=math.MaxInt64; that one is reported as math.MaxUint64.
+func (r *Range) Len() uint64 {
+	if r.End < r.Start || (r.End == r.Start && !r.Inclusive) {
+		return 0
+	}
+
+	n := uint64(r.End) - uint64(r.Start)
+	if r.Inclusive && n < math.MaxUint64 {
+		n++
+	}
+	return n
+}
+
+// At returns the integer at index i, which must be in [0, Len())
+func (r *Range) At(i uint64) int64 { return int64(uint64(r.Start) + i) }
+
+// Contains reports whether n lies in the range
+func (r *Range) Contains(n int64) bool {
+	if r.Inclusive {
+		return n >= r.Start && n <= r.End
+	}
+	return n >= r.Start && n < r.End
+}
+
+// Type returns the type of the object
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+
+// Inspect returns the string representation of the object
+func (r *Range) Inspect() string {
+	if r.Inclusive {
+		return fmt.Sprintf("%d..=%d", r.Start, r.End)
+	}
+	return fmt.Sprintf("%d..%d", r.Start, r.End)
+}
+
+// HashKey returns the hash key of the object. 0..=2 and 0..3 span the same integers and hash alike,
+// as do all empty ranges. The key is built from the start and the length, since the bound after
+// an inclusive end may overflow.
+func (r *Range) HashKey() HashKey {
+	var h = fnv.New64a()
+	var buf [16]byte
+
+	if r.Len() > 0 {
+		binary.LittleEndian.PutUint64(buf[:8], uint64(r.Start))
+	}
+	binary.LittleEndian.PutUint64(buf[8:], r.Len())
+	h.Write(buf[:])
+
+	return HashKey{Type: r.Type(), Value: h.Sum64()}
+}
@@ -15,8 +15,12 @@ type (
 
 const (
 	LOWEST      = iota + 1
+	ASSIGN      // =
+	PIPE        // |>
+	COALESCE    // ??
 	EQUALS      // ==
 	LESSGREATER // > or <
+	RANGE       // a..b
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
@@ -35,6 +39,15 @@ var precedences = map[token.Type]int{
 	token.ASTERISK: PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACK:   INDEX,
+	token.DOT:      INDEX,
+
+	token.ASSIGN:          ASSIGN,
+	token.PIPELINE:        PIPE,
+	token.NULLISH:         COALESCE,
+	token.RANGE:           RANGE,
+	token.RANGE_INCLUSIVE: RANGE,
+	token.OPTIONAL_DOT:    INDEX,
+	token.OPTIONAL_LBRACK: INDEX,
 }
 
 type Parser struct {
@@ -46,6 +59,9 @@ type Parser struct {
 
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
+
+	// yields holds one entry per function body being parsed, set once that body yields
+	yields []bool
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -60,13 +76,21 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE_HEAD, p.parseTemplateLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNull)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
+	p.registerPrefix(token.ASYNC, p.parseAsyncFunction)
+	p.registerPrefix(token.AWAIT, p.parseAwaitExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.PIPE, p.parseArrowFunction)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 	p.registerPrefix(token.LBRACK, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
@@ -81,6 +105,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACK, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parsePropertyExpression)
+	p.registerInfix(token.RANGE, p.parseRangeExpression)
+	p.registerInfix(token.RANGE_INCLUSIVE, p.parseRangeExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PIPELINE, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.OPTIONAL_DOT, p.parsePropertyExpression)
+	p.registerInfix(token.OPTIONAL_LBRACK, p.parseIndexExpression)
 
 	return p
 }
@@ -110,10 +142,20 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
+	case token.ENUM:
+		return p.parseEnumStatement()
+	case token.CLASS:
+		return p.parseClassStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -143,6 +185,266 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.AS) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekToken.IsType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	if p.peekToken.IsType(token.CONST) {
+		p.nextToken()
+	} else if !p.expectPeek(token.LET) {
+		return nil
+	}
+
+	stmt.Declaration = p.parseLetStatement()
+	if stmt.Declaration == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseStructStatement() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for !p.peekToken.IsType(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		if seen[p.curToken.Literal] {
+			msg := fmt.Sprintf("duplicate field %s in struct %s", p.curToken.Literal, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		seen[p.curToken.Literal] = true
+
+		stmt.Fields = append(stmt.Fields, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekToken.IsType(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekToken.IsType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for !p.peekToken.IsType(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		if seen[p.curToken.Literal] {
+			msg := fmt.Sprintf("duplicate variant %s in enum %s", p.curToken.Literal, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		seen[p.curToken.Literal] = true
+
+		variant := &ast.EnumVariant{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+		if p.peekToken.IsType(token.LPAREN) {
+			p.nextToken()
+			variant.Fields = p.parseFunctionParameters()
+			if !p.curToken.IsType(token.RPAREN) {
+				return nil
+			}
+		}
+
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !p.peekToken.IsType(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekToken.IsType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseClassStatement() *ast.ClassStatement {
+	stmt := &ast.ClassStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekToken.IsType(token.EXTENDS) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Superclass = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for !p.peekToken.IsType(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		if seen[p.curToken.Literal] {
+			msg := fmt.Sprintf("duplicate method %s in class %s", p.curToken.Literal, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		seen[p.curToken.Literal] = true
+
+		method := &ast.MethodDefinition{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		method.Function = &ast.FunctionLiteral{Token: token.Token{Type: token.FUNCTION, Literal: "fn"}}
+
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+
+		method.Function.Parameters = p.parseFunctionParameters()
+		if !p.curToken.IsType(token.RPAREN) {
+			return nil
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		p.enterFunction()
+		method.Function.Body = p.parseBlockStatement()
+		method.Function.IsGenerator = p.leaveFunction()
+		stmt.Methods = append(stmt.Methods, method)
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekToken.IsType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseAssignExpression parses target = value. Assignment is right-associative
+// and only properties can be assigned; variables are rebound with let.
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	target, ok := left.(*ast.PropertyExpression)
+	if !ok || target.Optional {
+		msg := fmt.Sprintf("invalid assignment target %s", left.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	expression := &ast.AssignExpression{Token: p.curToken, Target: target}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekToken.IsType(token.RBRACE) {
+		p.nextToken()
+		arm := &ast.MatchArm{Pattern: p.parseExpression(LOWEST)}
+
+		if !p.expectPeek(token.FAT_ARROW) {
+			return nil
+		}
+
+		p.nextToken()
+		arm.Body = p.parseExpression(LOWEST)
+		expression.Arms = append(expression.Arms, arm)
+
+		if !p.peekToken.IsType(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return expression
+}
+
 func (p *Parser) expectPeek(t token.Type) bool {
 	if p.peekToken.IsType(t) {
 		p.nextToken()
@@ -290,11 +592,28 @@ func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curToken.IsType(token.TRUE)}
 }
 
+func (p *Parser) parseNull() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+// parseGroupedExpression parses a parenthesized expression, or a tuple literal
+// when the parentheses are empty or hold a comma
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	tok := p.curToken
+
+	if p.peekToken.IsType(token.RPAREN) {
+		p.nextToken()
+		return &ast.TupleLiteral{Token: tok}
+	}
+
 	p.nextToken()
 
 	exp := p.parseExpression(LOWEST)
 
+	if p.peekToken.IsType(token.COMMA) {
+		return p.parseTupleLiteral(tok, exp)
+	}
+
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
@@ -302,6 +621,27 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	return exp
 }
 
+// parseTupleLiteral parses the rest of a tuple literal whose first element is first.
+// A trailing comma is allowed, so (x,) is a tuple of one element.
+func (p *Parser) parseTupleLiteral(tok token.Token, first ast.Expression) ast.Expression {
+	tuple := &ast.TupleLiteral{Token: tok, Elements: []ast.Expression{first}}
+
+	for p.peekToken.IsType(token.COMMA) {
+		p.nextToken()
+		if p.peekToken.IsType(token.RPAREN) {
+			break
+		}
+		p.nextToken()
+		tuple.Elements = append(tuple.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return tuple
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: p.curToken}
 
@@ -364,11 +704,132 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	p.enterFunction()
 	lit.Body = p.parseBlockStatement()
+	lit.IsGenerator = p.leaveFunction()
+
+	return lit
+}
+
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+// parseArrowFunction parses the shorthand |x, y| expr, which desugars to fn(x, y) { expr }
+func (p *Parser) parseArrowFunction() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: token.Token{Type: token.FUNCTION, Literal: "fn"}}
+
+	if !p.peekToken.IsType(token.PIPE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		lit.Parameters = append(lit.Parameters, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		for p.peekToken.IsType(token.COMMA) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			lit.Parameters = append(lit.Parameters, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		}
+	}
+
+	if !p.expectPeek(token.PIPE) {
+		return nil
+	}
+
+	p.nextToken()
+
+	body := &ast.ExpressionStatement{Token: p.curToken}
+	p.enterFunction()
+	body.Expression = p.parseExpression(LOWEST)
+	lit.IsGenerator = p.leaveFunction()
+	lit.Body = &ast.BlockStatement{Token: body.Token, Statements: []ast.Statement{body}}
+
+	return lit
+}
+
+// parseAsyncFunction parses async fn(...) { ... } and async |...| expr
+func (p *Parser) parseAsyncFunction() ast.Expression {
+	p.nextToken()
+
+	var fn ast.Expression
+	switch p.curToken.Type {
+	case token.FUNCTION:
+		fn = p.parseFunctionLiteral()
+	case token.PIPE:
+		fn = p.parseArrowFunction()
+	default:
+		msg := fmt.Sprintf("expected function after async, got %s instead", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit, ok := fn.(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	lit.IsAsync = true
 
 	return lit
 }
 
+func (p *Parser) parseAwaitExpression() ast.Expression {
+	expression := &ast.AwaitExpression{Token: p.curToken}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(PREFIX)
+
+	return expression
+}
+
+// enterFunction starts tracking whether the function body about to be parsed yields
+func (p *Parser) enterFunction() {
+	p.yields = append(p.yields, false)
+}
+
+// leaveFunction stops tracking the innermost function body and reports whether it yielded
+func (p *Parser) leaveFunction() bool {
+	last := len(p.yields) - 1
+	yielded := p.yields[last]
+	p.yields = p.yields[:last]
+	return yielded
+}
+
+func (p *Parser) parseYieldExpression() ast.Expression {
+	expression := &ast.YieldExpression{Token: p.curToken}
+
+	if len(p.yields) == 0 {
+		p.errors = append(p.errors, "yield outside function")
+		return nil
+	}
+	p.yields[len(p.yields)-1] = true
+
+	if p.peekToken.IsType(token.ASTERISK) {
+		p.nextToken()
+		expression.Delegate = true
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(LOWEST)
+
+	return expression
+}
+
 func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	var identifiers []*ast.Identifier
 
@@ -406,9 +867,45 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	template := &ast.TemplateLiteral{Token: p.curToken}
+	template.Parts = append(template.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+
+	for !p.curToken.IsType(token.TEMPLATE_TAIL) {
+		p.nextToken()
+		template.Parts = append(template.Parts, p.parseExpression(LOWEST))
+
+		switch p.peekToken.Type {
+		case token.TEMPLATE_MIDDLE, token.TEMPLATE_TAIL:
+			p.nextToken()
+		default:
+			p.peekError(token.TEMPLATE_TAIL)
+			return nil
+		}
+
+		template.Parts = append(template.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	return template
+}
+
+// parseArrayLiteral parses an array literal, or a list comprehension when the first element is followed by for
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
-	array.Elements = p.parseExpressionList(token.RBRACK)
+
+	if p.peekToken.IsType(token.RBRACK) {
+		p.nextToken()
+		return array
+	}
+
+	p.nextToken()
+	first := p.parseExpression(LOWEST)
+
+	if p.peekToken.IsType(token.FOR) {
+		return p.parseListComprehension(array.Token, first)
+	}
+
+	array.Elements = p.parseRemainingExpressions([]ast.Expression{first}, token.RBRACK)
 	return array
 }
 
@@ -423,6 +920,11 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 	p.nextToken()
 	list = append(list, p.parseExpression(LOWEST))
 
+	return p.parseRemainingExpressions(list, end)
+}
+
+// parseRemainingExpressions parses the comma-separated expressions that follow list, up to end
+func (p *Parser) parseRemainingExpressions(list []ast.Expression, end token.Type) []ast.Expression {
 	for p.peekToken.IsType(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
@@ -436,11 +938,112 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 	return list
 }
 
+// parseListComprehension parses the rest of [element for x in iterable if condition]
+func (p *Parser) parseListComprehension(tok token.Token, element ast.Expression) ast.Expression {
+	comprehension := &ast.ListComprehension{Token: tok, Element: element}
+
+	if !p.parseComprehensionClause(&comprehension.Clause) || !p.expectPeek(token.RBRACK) {
+		return nil
+	}
+
+	return comprehension
+}
+
+// parseHashComprehension parses the rest of {key: value for k, v in iterable if condition}
+func (p *Parser) parseHashComprehension(tok token.Token, key, value ast.Expression) ast.Expression {
+	comprehension := &ast.HashComprehension{Token: tok, Key: key, Value: value}
+
+	if !p.parseComprehensionClause(&comprehension.Clause) || !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return comprehension
+}
+
+// parseComprehensionClause parses `for a, b in iterable` and an optional `if condition`.
+// The peek token must be for; it stops before the token closing the comprehension.
+func (p *Parser) parseComprehensionClause(clause *ast.ComprehensionClause) bool {
+	p.nextToken()
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
+		clause.Variables = append(clause.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekToken.IsType(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if len(clause.Variables) > 2 {
+		msg := fmt.Sprintf("too many loop variables in comprehension. got=%d, want=1 or 2", len(clause.Variables))
+		p.errors = append(p.errors, msg)
+		return false
+	}
+
+	if !p.expectPeek(token.IN) {
+		return false
+	}
+
+	p.nextToken()
+	clause.Iterable = p.parseExpression(LOWEST)
+
+	if p.peekToken.IsType(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		clause.Condition = p.parseExpression(LOWEST)
+	}
+
+	return true
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
+
+	var index ast.Expression
+	if !p.peekToken.IsType(token.COLON) {
+		p.nextToken()
+		index = p.parseExpression(LOWEST)
+	}
+
+	if p.peekToken.IsType(token.COLON) {
+		return p.parseSliceExpression(tok, left, index)
+	}
+
+	exp := &ast.IndexExpression{Token: tok, Left: left, Index: index, Optional: tok.IsType(token.OPTIONAL_LBRACK)}
+
+	if !p.expectPeek(token.RBRACK) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parsePropertyExpression(left ast.Expression) ast.Expression {
+	exp := &ast.PropertyExpression{Token: p.curToken, Left: left, Optional: p.curToken.IsType(token.OPTIONAL_DOT)}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+// parseSliceExpression parses the rest of left[start:end:step] with the current token on start
+func (p *Parser) parseSliceExpression(tok token.Token, left ast.Expression, start ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: tok, Left: left, Start: start, Optional: tok.IsType(token.OPTIONAL_LBRACK)}
 
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	exp.End = p.parseSliceBound()
+
+	if p.peekToken.IsType(token.COLON) {
+		p.nextToken()
+		exp.Step = p.parseSliceBound()
+	}
 
 	if !p.expectPeek(token.RBRACK) {
 		return nil
@@ -449,14 +1052,27 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// parseSliceBound parses an optional slice bound following the current ':' token
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekToken.IsType(token.COLON) || p.peekToken.IsType(token.RBRACK) {
+		return nil
+	}
+
+	p.nextToken()
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
 
 	for !p.peekToken.IsType(token.RBRACE) {
 		p.nextToken()
 		key := p.parseExpression(LOWEST)
 
+		if len(hash.Pairs) == 0 && !p.peekToken.IsType(token.COLON) {
+			return p.parseSetLiteral(hash.Token, key)
+		}
+
 		if !p.expectPeek(token.COLON) {
 			return nil
 		}
@@ -464,7 +1080,11 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
-		hash.Pairs[key] = value
+		if len(hash.Pairs) == 0 && p.peekToken.IsType(token.FOR) {
+			return p.parseHashComprehension(hash.Token, key, value)
+		}
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
 		if !p.peekToken.IsType(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -477,3 +1097,39 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 	return hash
 }
+
+// parseRangeExpression parses start..end and start..=end. The end binds like the right
+// operand of an infix operator, so 0..n + 1 ends at n + 1.
+func (p *Parser) parseRangeExpression(start ast.Expression) ast.Expression {
+	expression := &ast.RangeExpression{
+		Token:     p.curToken,
+		Start:     start,
+		Inclusive: p.curToken.IsType(token.RANGE_INCLUSIVE),
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.End = p.parseExpression(precedence)
+
+	return expression
+}
+
+// parseSetLiteral parses the rest of a set literal whose first element is first
+func (p *Parser) parseSetLiteral(tok token.Token, first ast.Expression) ast.Expression {
+	set := &ast.SetLiteral{Token: tok, Elements: []ast.Expression{first}}
+
+	for p.peekToken.IsType(token.COMMA) {
+		p.nextToken()
+		if p.peekToken.IsType(token.RBRACE) {
+			break
+		}
+		p.nextToken()
+		set.Elements = append(set.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return set
+}
@@ -18,6 +18,7 @@ func TestLetStatements(t *testing.T) {
 		{"let x = 5;", "x", 5},
 		{"let y = true;", "y", true},
 		{"let foobar = y;", "foobar", "y"},
+		{"const z = 1;", "z", 1},
 	}
 
 	for _, tt := range tests {
@@ -248,6 +249,27 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
 		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
 		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"a[1:2]", "(a[1:2])"},
+		{"a[:b + 1]", "(a[:(b + 1)])"},
+		{"a[1:]", "(a[1:])"},
+		{"a[:]", "(a[:])"},
+		{"a[::-1]", "(a[::(-1)])"},
+		{"a[1:5:2][0]", "((a[1:5:2])[0])"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a?.b?[c] ?? null", "(((a?.b)?[c]) ?? null)"},
+		{"a?[1:]", "(a?[1:])"},
+		{"cfg.server.port", "((cfg.server).port)"},
+		{"-a.b * c", "((-(a.b)) * c)"},
+		{"s.upper()", "(s.upper)()"},
+		{"xs.map(f)[0]", "((xs.map)(f)[0])"},
+		{"xs |> map(f) |> sum()", "((xs |> map(f)) |> sum())"},
+		{"a + 1 |> f()", "((a + 1) |> f())"},
+		{"a |> f() ?? b", "(a |> (f() ?? b))"},
+		{"|x| x * 2", "fn(x) (x * 2)"},
+		{"|| 1", "fn() 1"},
+		{"map(xs, |x, y| x + y)", "map(xs, fn(x, y) (x + y))"},
+		{"|x| |y| x + y", "fn(x) fn(y) (x + y)"},
 	}
 
 	for _, tt := range tests {
@@ -603,12 +625,12 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		"three": 3,
 	}
 
-	for k, v := range hash.Pairs {
-		literal, ok := k.(*ast.StringLiteral)
-		require.True(t, ok, "key is not ast.StringLiteral. got=%T", k)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		require.True(t, ok, "key is not ast.StringLiteral. got=%T", pair.Key)
 
 		expectedValue := expected[literal.String()]
-		testIntegerLiteral(t, v, expectedValue)
+		testIntegerLiteral(t, pair.Value, expectedValue)
 	}
 }
 
@@ -659,13 +681,609 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for k, v := range hash.Pairs {
-		literal, ok := k.(*ast.StringLiteral)
-		require.True(t, ok, "key is not ast.StringLiteral. got=%T", k)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		require.True(t, ok, "key is not ast.StringLiteral. got=%T", pair.Key)
 
 		testFunc, ok := tests[literal.String()]
 		require.True(t, ok, "no test function for key %q found", literal.String())
 
-		testFunc(v)
+		testFunc(pair.Value)
 	}
 }
+
+func TestParsingHashLiteralsKeepSourceOrder(t *testing.T) {
+	input := `{"c": 1, "a": 2, "b": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	require.True(t, ok, "exp is not ast.HashLiteral. got=%T", stmt.Expression)
+
+	expectedKeys := []string{"c", "a", "b"}
+	require.Equal(t, len(expectedKeys), len(hash.Pairs), "hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+
+	for i, key := range expectedKeys {
+		assert.Equal(t, key, hash.Pairs[i].Key.String(), "hash.Pairs[%d] has wrong key", i)
+		testIntegerLiteral(t, hash.Pairs[i].Value, int64(i+1))
+	}
+
+	assert.Equal(t, "{c:1, a:2, b:3}", hash.String(), "hash.String() wrong")
+}
+
+func TestParsingTemplateLiteral(t *testing.T) {
+	input := `"Hello ${name}, you are ${age + 1}"`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+
+	template, ok := stmt.Expression.(*ast.TemplateLiteral)
+	require.True(t, ok, "exp is not ast.TemplateLiteral. got=%T", stmt.Expression)
+
+	require.Equal(t, 5, len(template.Parts), "template.Parts has wrong length. got=%d", len(template.Parts))
+
+	assert.Equal(t, "Hello ", template.Parts[0].(*ast.StringLiteral).Value)
+	testIdentifier(t, template.Parts[1], "name")
+	assert.Equal(t, ", you are ", template.Parts[2].(*ast.StringLiteral).Value)
+	testInfixExpression(t, template.Parts[3], "age", "+", 1)
+	assert.Equal(t, "", template.Parts[4].(*ast.StringLiteral).Value)
+
+	assert.Equal(t, `"Hello ${name}, you are ${(age + 1)}"`, template.String())
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	input := "myArray[1:a + 1:2]"
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+
+	sliceExp, ok := stmt.Expression.(*ast.SliceExpression)
+	require.True(t, ok, "exp is not ast.SliceExpression. got=%T", stmt.Expression)
+
+	testIdentifier(t, sliceExp.Left, "myArray")
+	testIntegerLiteral(t, sliceExp.Start, 1)
+	testInfixExpression(t, sliceExp.End, "a", "+", 1)
+	testIntegerLiteral(t, sliceExp.Step, 2)
+}
+
+func TestArrowFunctionParsing(t *testing.T) {
+	input := "|x, y| x + y;"
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 1, len(program.Statements), "program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+	require.True(t, ok, "stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+
+	require.Equal(t, 2, len(function.Parameters), "function literal parameters wrong. want 2, got=%d", len(function.Parameters))
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	require.Equal(t, 1, len(function.Body.Statements), "function.Body.Statements does not contain 1 statements. got=%d", len(function.Body.Statements))
+
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "function body stmt is not ast.ExpressionStatement. got=%T", function.Body.Statements[0])
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestArrowFunctionParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"|1| 1", "expected next token to be IDENT, got INT instead"},
+		{"|x, | x", "expected next token to be IDENT, got | instead"},
+		{"|x 1", "expected next token to be |, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "expected parser errors for %q", tt.input)
+		assert.Equal(t, tt.expectedError, p.Errors()[0])
+	}
+}
+
+func TestImportAndExportStatements(t *testing.T) {
+	input := `import "lib/math.mk" as math;
+export let square = fn(x) { x * x };`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 2, len(program.Statements), "program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+
+	importStmt, ok := program.Statements[0].(*ast.ImportStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ImportStatement. got=%T", program.Statements[0])
+	assert.Equal(t, "lib/math.mk", importStmt.Path.Value)
+	testIdentifier(t, importStmt.Alias, "math")
+
+	exportStmt, ok := program.Statements[1].(*ast.ExportStatement)
+	require.True(t, ok, "program.Statements[1] is not ast.ExportStatement. got=%T", program.Statements[1])
+	assert.Equal(t, "square", exportStmt.Declaration.Name.Value)
+
+	assert.Equal(t, `import "lib/math.mk" as math;export let square = fn(x) (x * x);`, program.String())
+}
+
+func TestImportAndExportStatementErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`import math`, "expected next token to be STRING, got IDENT instead"},
+		{`import "math.mk"`, "expected next token to be AS, got EOF instead"},
+		{`export 1`, "expected next token to be LET, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "expected parser errors for %q", tt.input)
+		assert.Equal(t, tt.expectedError, p.Errors()[0])
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	input := "const x = 1; let y = 2; export const z = 3;"
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 3, len(program.Statements), "program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+
+	assert.True(t, program.Statements[0].(*ast.LetStatement).IsConst(), "const statement is not const")
+	assert.False(t, program.Statements[1].(*ast.LetStatement).IsConst(), "let statement is const")
+	assert.True(t, program.Statements[2].(*ast.ExportStatement).Declaration.IsConst(), "exported const statement is not const")
+
+	assert.Equal(t, "const x = 1;let y = 2;export const z = 3;", program.String())
+}
+
+func TestStructStatements(t *testing.T) {
+	input := "struct Point { x, y }; struct Empty {}"
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 2, len(program.Statements), "program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+
+	stmt, ok := program.Statements[0].(*ast.StructStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.StructStatement. got=%T", program.Statements[0])
+	testIdentifier(t, stmt.Name, "Point")
+	require.Equal(t, 2, len(stmt.Fields))
+	testIdentifier(t, stmt.Fields[0], "x")
+	testIdentifier(t, stmt.Fields[1], "y")
+
+	assert.Equal(t, "struct Point { x, y }struct Empty {  }", program.String())
+}
+
+func TestStructStatementErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`struct { x }`, "expected next token to be IDENT, got { instead"},
+		{`struct Point { x y }`, "expected next token to be ,, got IDENT instead"},
+		{`struct Point { x, x }`, "duplicate field x in struct Point"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "expected parser errors for %q", tt.input)
+		assert.Equal(t, tt.expectedError, p.Errors()[0])
+	}
+}
+
+func TestEnumStatements(t *testing.T) {
+	input := "enum Result { Ok(value), Err(msg), Empty, }"
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 1, len(program.Statements), "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+
+	stmt, ok := program.Statements[0].(*ast.EnumStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.EnumStatement. got=%T", program.Statements[0])
+	testIdentifier(t, stmt.Name, "Result")
+	require.Equal(t, 3, len(stmt.Variants))
+	testIdentifier(t, stmt.Variants[0].Name, "Ok")
+	require.Equal(t, 1, len(stmt.Variants[0].Fields))
+	testIdentifier(t, stmt.Variants[0].Fields[0], "value")
+	assert.Empty(t, stmt.Variants[2].Fields)
+
+	assert.Equal(t, "enum Result { Ok(value), Err(msg), Empty }", program.String())
+}
+
+func TestMatchExpression(t *testing.T) {
+	input := `match r { Ok(v) => v + 1, Err(_) => 0, }`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 1, len(program.Statements), "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+
+	exp, ok := stmt.Expression.(*ast.MatchExpression)
+	require.True(t, ok, "stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+	testIdentifier(t, exp.Subject, "r")
+	require.Equal(t, 2, len(exp.Arms))
+
+	_, ok = exp.Arms[0].Pattern.(*ast.CallExpression)
+	assert.True(t, ok, "arm pattern is not ast.CallExpression. got=%T", exp.Arms[0].Pattern)
+	testInfixExpression(t, exp.Arms[0].Body, "v", "+", 1)
+
+	assert.Equal(t, "match r { Ok(v) => (v + 1), Err(_) => 0 }", program.String())
+}
+
+func TestEnumAndMatchErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`enum Result { Ok(value) Err }`, "expected next token to be ,, got IDENT instead"},
+		{`enum Result { Ok, Ok }`, "duplicate variant Ok in enum Result"},
+		{`match x { 1 2 }`, "expected next token to be =>, got INT instead"},
+		{`match x { 1 => 2 3 => 4 }`, "expected next token to be ,, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "expected parser errors for %q", tt.input)
+		assert.Equal(t, tt.expectedError, p.Errors()[0])
+	}
+}
+
+func TestClassStatements(t *testing.T) {
+	input := `class Dog extends Animal { init(name) { self.name = name } speak() { "woof" } }`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 1, len(program.Statements), "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+
+	stmt, ok := program.Statements[0].(*ast.ClassStatement)
+	require.True(t, ok, "program.Statements[0] is not ast.ClassStatement. got=%T", program.Statements[0])
+	testIdentifier(t, stmt.Name, "Dog")
+	testIdentifier(t, stmt.Superclass, "Animal")
+	require.Equal(t, 2, len(stmt.Methods))
+	testIdentifier(t, stmt.Methods[0].Name, "init")
+	require.Equal(t, 1, len(stmt.Methods[0].Function.Parameters))
+	testIdentifier(t, stmt.Methods[1].Name, "speak")
+
+	assert.Equal(t, `class Dog extends Animal { init(name) ((self.name) = name) speak() woof }`, program.String())
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"self.x = 1", "((self.x) = 1)"},
+		{"a.b = c.d = 1 + 2", "((a.b) = ((c.d) = (1 + 2)))"},
+		{"a.b.c = x |> f", "(((a.b).c) = (x |> f))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+}
+
+func TestClassAndAssignErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`class A extends { }`, "expected next token to be IDENT, got { instead"},
+		{`class A { f() { 1 } f() { 2 } }`, "duplicate method f in class A"},
+		{`class A { f { 1 } }`, "expected next token to be (, got { instead"},
+		{`x = 1`, "invalid assignment target x"},
+		{`a?.b = 1`, "invalid assignment target (a?.b)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "expected parser errors for %q", tt.input)
+		assert.Equal(t, tt.expectedError, p.Errors()[0])
+	}
+}
+
+func TestYieldExpressions(t *testing.T) {
+	tests := []struct {
+		input       string
+		isGenerator bool
+		expected    string
+	}{
+		{"fn(n) { yield n; yield* f(n + 1) }", true, "fn(n) yield nyield* f((n + 1))"},
+		{"|x| yield x * 2", true, "fn(x) yield (x * 2)"},
+		{"fn() { fn() { yield 1 } }", false, "fn() fn() yield 1"},
+		{"fn() { 1 }", false, "fn() 1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		require.True(t, ok, "stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+
+		assert.Equal(t, tt.isGenerator, function.IsGenerator, "IsGenerator wrong for %q", tt.input)
+		assert.Equal(t, tt.expected, program.String())
+	}
+
+	l := lexer.New("class Counter { count() { yield 1 } }")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	class := program.Statements[0].(*ast.ClassStatement)
+	assert.True(t, class.Methods[0].Function.IsGenerator, "generator method is not a generator")
+
+	l = lexer.New("yield 1")
+	p = New(l)
+	p.ParseProgram()
+
+	require.NotEmpty(t, p.Errors(), "expected parser errors for top-level yield")
+	assert.Equal(t, "yield outside function", p.Errors()[0])
+}
+
+func TestAsyncAndAwaitParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"async fn(x) { await f(x) }", "async fn(x) (await f(x))"},
+		{"async |x| await x + 1", "async fn(x) ((await x) + 1)"},
+		{"await a.b(1) * 2", "((await (a.b)(1)) * 2)"},
+		{"await -x", "(await (-x))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+
+	l := lexer.New("async fn() { 1 }")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	assert.True(t, function.IsAsync, "async function is not async")
+
+	l = lexer.New("async 1")
+	p = New(l)
+	p.ParseProgram()
+
+	require.NotEmpty(t, p.Errors(), "expected parser errors for async without function")
+	assert.Equal(t, "expected function after async, got INT instead", p.Errors()[0])
+}
+
+func TestSetAndTupleLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{1, 2 + 3}", "{1, (2 + 3)}"},
+		{"{x}", "{x}"},
+		{"{1, 2,}", "{1, 2}"},
+		{"{}", "{}"},
+		{"(1, 2)", "(1, 2)"},
+		{"(1,)", "(1,)"},
+		{"()", "()"},
+		{"(1)", "1"},
+		{"((1, 2), {(3, 4)})", "((1, 2), {(3, 4)})"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+
+	l := lexer.New("{1, 2}; (1, 2)")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	set, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SetLiteral)
+	require.True(t, ok, "expression is not ast.SetLiteral. got=%T", program.Statements[0])
+	assert.Len(t, set.Elements, 2)
+
+	tuple, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.TupleLiteral)
+	require.True(t, ok, "expression is not ast.TupleLiteral. got=%T", program.Statements[1])
+	assert.Len(t, tuple.Elements, 2)
+
+	l = lexer.New("{1, 2: 3}")
+	p = New(l)
+	p.ParseProgram()
+
+	require.NotEmpty(t, p.Errors(), "expected parser errors for mixed set and hash literal")
+	assert.Equal(t, "expected next token to be }, got : instead", p.Errors()[0])
+}
+
+func TestRangeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0..n", "(0..n)"},
+		{"1..=10", "(1..=10)"},
+		{"0..n + 1", "(0..(n + 1))"},
+		{"a..b == c..=d", "((a..b) == (c..=d))"},
+		{"xs[1..3]", "(xs[(1..3)])"},
+		{"-1..x.len()", "((-1)..(x.len)())"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+
+	l := lexer.New("0..=5")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	rng, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.RangeExpression)
+	require.True(t, ok, "expression is not ast.RangeExpression. got=%T", program.Statements[0])
+	assert.True(t, rng.Inclusive, "..= range is not inclusive")
+}
+
+func TestComprehensionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x * 2 for x in xs if x > 0]", "[(x * 2) for x in xs if (x > 0)]"},
+		{"[x for x in 0..n]", "[x for x in (0..n)]"},
+		{"[a + b for a, b in pairs]", "[(a + b) for a, b in pairs]"},
+		{"{k: v for k, v in h}", "{k:v for k, v in h}"},
+		{"{x: x * x for x in xs if x != 0}", "{x:(x * x) for x in xs if (x != 0)}"},
+		{"[[y for y in x] for x in xss]", "[[y for y in x] for x in xss]"},
+		{"[1, 2]", "[1, 2]"},
+		{"[]", "[]"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equal(t, tt.expected, program.String())
+	}
+
+	l := lexer.New("[x for x in xs if x]; {k: v for k, v in h}")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	list, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ListComprehension)
+	require.True(t, ok, "expression is not ast.ListComprehension. got=%T", program.Statements[0])
+	assert.Len(t, list.Clause.Variables, 1)
+	assert.NotNil(t, list.Clause.Condition)
+
+	hash, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.HashComprehension)
+	require.True(t, ok, "expression is not ast.HashComprehension. got=%T", program.Statements[1])
+	assert.Len(t, hash.Clause.Variables, 2)
+	assert.Nil(t, hash.Clause.Condition)
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x for a, b, c in xs]", "too many loop variables in comprehension. got=3, want=1 or 2"},
+		{"[x for x xs]", "expected next token to be IN, got IDENT instead"},
+		{"[x for 1 in xs]", "expected next token to be IDENT, got INT instead"},
+		{"[x for x in xs, y]", "expected next token to be ], got , instead"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "expected parser errors for %q", tt.input)
+		assert.Equal(t, tt.expected, p.Errors()[0])
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	l := lexer.New(`macro(x, y) { x + y; }`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Len(t, program.Statements, 1)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok, "statement is not ast.ExpressionStatement. got=%T", program.Statements[0])
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	require.True(t, ok, "expression is not ast.MacroLiteral. got=%T", stmt.Expression)
+
+	require.Len(t, macro.Parameters, 2)
+	assert.Equal(t, "x", macro.Parameters[0].Value)
+	assert.Equal(t, "y", macro.Parameters[1].Value)
+	require.Len(t, macro.Body.Statements, 1)
+	assert.Equal(t, "(x + y)", macro.Body.String())
+	assert.Equal(t, "macro(x, y) (x + y)", macro.String())
+}
@@ -15,6 +15,8 @@ const PROMPT = ">> "
 func Start(in io.Reader, out io.Writer) error {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	macroEnv := object.NewEnvironment()
+	evalutor.NewModuleLoader().Install(env, ".")
 
 	for {
 		fmt.Printf(PROMPT)
@@ -35,7 +37,16 @@ func Start(in io.Reader, out io.Writer) error {
 			continue
 		}
 
-		evaluated := evalutor.Eval(program, env)
+		evalutor.DefineMacros(program, macroEnv)
+		expanded, expansionErr := evalutor.ExpandMacros(program, macroEnv)
+		if expansionErr != nil {
+			if _, err := io.WriteString(out, expansionErr.Inspect()+"\n"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		evaluated := evalutor.Eval(expanded, env)
 		if evaluated != nil {
 			if _, err := io.WriteString(out, evaluated.Inspect()); err != nil {
 				return err
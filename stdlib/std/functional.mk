@@ -0,0 +1,18 @@
+export let identity = fn(x) { x };
+
+export let constant = fn(x) { fn() { x } };
+
+export let compose = fn(f, g) { fn(x) { f(g(x)) } };
+
+export let pipe = fn(fns) { fn(x) { reduce(fns, x, fn(acc, f) { f(acc) }) } };
+
+export let partial = fn(f, a) { fn(b) { f(a, b) } };
+
+export let flip = fn(f) { fn(a, b) { f(b, a) } };
+
+export let times = fn(n, f) {
+  let iter = fn(i, acc) {
+    if (i < n) { iter(i + 1, push(acc, f(i))) } else { acc }
+  };
+  iter(0, []);
+};
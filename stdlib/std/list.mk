@@ -0,0 +1,30 @@
+export let range = fn(n) {
+  let iter = fn(i, acc) {
+    if (i < n) { iter(i + 1, push(acc, i)) } else { acc }
+  };
+  iter(0, []);
+};
+
+export let reverse = fn(xs) { xs[::-1] };
+
+export let take = fn(xs, n) { xs[:n] };
+
+export let drop = fn(xs, n) { xs[n:] };
+
+export let find = fn(xs, f) { first(filter(xs, f)) };
+
+export let any = fn(xs, f) { len(filter(xs, f)) > 0 };
+
+export let all = fn(xs, f) { len(filter(xs, f)) == len(xs) };
+
+export let flatten = fn(xs) {
+  reduce(xs, [], fn(acc, x) { reduce(x, acc, fn(inner, y) { push(inner, y) }) });
+};
+
+export let zip = fn(a, b) {
+  let n = if (len(a) < len(b)) { len(a) } else { len(b) };
+  let iter = fn(i, acc) {
+    if (i < n) { iter(i + 1, push(acc, [a[i], b[i]])) } else { acc }
+  };
+  iter(0, []);
+};
@@ -0,0 +1,17 @@
+export let is_empty = fn(s) { len(s) == 0 };
+
+export let capitalize = fn(s) {
+  if (len(s) == 0) { s } else { upper(s[:1]) + s[1:] }
+};
+
+export let pad_left = fn(s, width, pad) {
+  if (len(s) < width) { repeat(pad, width - len(s)) + s } else { s }
+};
+
+export let pad_right = fn(s, width, pad) {
+  if (len(s) < width) { s + repeat(pad, width - len(s)) } else { s }
+};
+
+export let words = fn(s) { filter(split(trim(s), " "), fn(w) { len(w) > 0 }) };
+
+export let reverse = fn(s) { s[::-1] };
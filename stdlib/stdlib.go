@@ -0,0 +1,9 @@
+// Package stdlib bundles the Monkey standard library, served to scripts by load("std/...").
+package stdlib
+
+import "embed"
+
+// Files holds the standard library sources, e.g. std/list.mk for load("std/list")
+//
+//go:embed std
+var Files embed.FS
@@ -20,6 +20,12 @@ const (
 	INT    = "INT"   // 1343456
 	STRING = "STRING"
 
+	// Template literals: "a ${x} b ${y} c" lexes as
+	// TEMPLATE_HEAD("a ") x TEMPLATE_MIDDLE(" b ") y TEMPLATE_TAIL(" c")
+	TEMPLATE_HEAD   = "TEMPLATE_HEAD"
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE"
+	TEMPLATE_TAIL   = "TEMPLATE_TAIL"
+
 	// Operators
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -30,6 +36,15 @@ const (
 	LT       = "<"
 	GT       = ">"
 
+	PIPELINE  = "|>"
+	PIPE      = "|"
+	FAT_ARROW = "=>"
+
+	// Null-safe operators
+	NULLISH         = "??"
+	OPTIONAL_DOT    = "?."
+	OPTIONAL_LBRACK = "?["
+
 	// Equality
 	EQ     = "=="
 	NOT_EQ = "!="
@@ -38,6 +53,11 @@ const (
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
+
+	// Ranges
+	RANGE           = ".."
+	RANGE_INCLUSIVE = "..="
 
 	LPAREN = "("
 	RPAREN = ")"
@@ -49,11 +69,27 @@ const (
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	NULL     = "NULL"
+	IMPORT   = "IMPORT"
+	AS       = "AS"
+	EXPORT   = "EXPORT"
+	STRUCT   = "STRUCT"
+	ENUM     = "ENUM"
+	MATCH    = "MATCH"
+	CLASS    = "CLASS"
+	EXTENDS  = "EXTENDS"
+	YIELD    = "YIELD"
+	ASYNC    = "ASYNC"
+	AWAIT    = "AWAIT"
+	FOR      = "FOR"
+	IN       = "IN"
+	MACRO    = "MACRO"
 )
 
 func New(tokenType Type, ch byte) Token {
@@ -61,13 +97,29 @@ func New(tokenType Type, ch byte) Token {
 }
 
 var keywords = map[string]Type{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"const":   CONST,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"null":    NULL,
+	"import":  IMPORT,
+	"as":      AS,
+	"export":  EXPORT,
+	"struct":  STRUCT,
+	"enum":    ENUM,
+	"match":   MATCH,
+	"class":   CLASS,
+	"extends": EXTENDS,
+	"yield":   YIELD,
+	"async":   ASYNC,
+	"await":   AWAIT,
+	"for":     FOR,
+	"in":      IN,
+	"macro":   MACRO,
 }
 
 func LookupIdent(ident string) Type {